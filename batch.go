@@ -0,0 +1,235 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultBatchWindow   = 20 * time.Millisecond
+	defaultBatchMaxItems = 50
+	defaultBatchMaxChars = 4000
+
+	// batchSeparator joins the texts of a batch. Google keeps line breaks, so the
+	// translation splits back into one line per text; texts containing a line
+	// break are never batched.
+	batchSeparator = "\n"
+)
+
+// BatcherConfig configures a Batcher.
+type BatcherConfig struct {
+	// Window is how long the first call of a batch waits for others to join. Defaults to 20ms.
+	Window time.Duration
+	// MaxItems is the number of calls after which a batch is sent at once. Defaults to 50.
+	MaxItems int
+	// MaxChars is the number of characters of a batch, separators included, after which it is sent at once.
+	// Defaults to 4000.
+	MaxChars int
+}
+
+// Batcher gathers Translate calls for the same language pair that arrive within
+// a short window and sends them as one upstream request, splitting the
+// translation back to each caller. It cuts the number of requests of services
+// that translate many short texts concurrently.
+//
+// Calls with an "auto" source language, texts containing line breaks and texts
+// longer than MaxChars are translated on their own, as are the texts of a batch
+// whose translation does not split back into as many lines as it had texts.
+//
+// A Batcher embeds its Translator for the methods it does not batch, so it
+// satisfies server.Backend and can serve the server package. It is not a
+// *Translator: code taking one must be given the Translator itself, whose
+// calls are not batched.
+type Batcher struct {
+	*Translator
+	config BatcherConfig
+
+	mu      sync.Mutex
+	pending map[batchKey]*batch
+	closed  bool
+	wg      sync.WaitGroup // batches being sent
+}
+
+type batchKey struct {
+	src, dest string
+}
+
+// batch is a set of calls waiting to be sent together.
+type batch struct {
+	calls []*batchCall
+	chars int
+	timer *time.Timer
+}
+
+type batchCall struct {
+	ctx    context.Context
+	origin string
+	done   chan struct{}
+	result *Translated
+	err    error
+}
+
+// NewBatcher returns a Batcher sending the batches through t.
+// Zero values in config are replaced by defaults.
+func NewBatcher(t *Translator, config ...BatcherConfig) *Batcher {
+	var c BatcherConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Window <= 0 {
+		c.Window = defaultBatchWindow
+	}
+	if c.MaxItems <= 0 {
+		c.MaxItems = defaultBatchMaxItems
+	}
+	if c.MaxChars <= 0 {
+		c.MaxChars = defaultBatchMaxChars
+	}
+	return &Batcher{Translator: t, config: c, pending: map[batchKey]*batch{}}
+}
+
+// Translate is like TranslateContext with context.Background().
+func (b *Batcher) Translate(origin, src, dest string) (*Translated, error) {
+	return b.TranslateContext(context.Background(), origin, src, dest)
+}
+
+// TranslateContext translates origin like Translator.TranslateContext, batching
+// it with other calls for the same language pair. A caller whose context is
+// done stops waiting without affecting the rest of its batch.
+func (b *Batcher) TranslateContext(ctx context.Context, origin, src, dest string) (*Translated, error) {
+	src = strings.ToLower(src)
+	dest = strings.ToLower(dest)
+	chars := utf8.RuneCountInString(origin)
+	if src == "auto" || strings.Contains(origin, batchSeparator) || chars >= b.config.MaxChars {
+		return b.Translator.TranslateContext(ctx, origin, src, dest)
+	}
+
+	call := &batchCall{ctx: ctx, origin: origin, done: make(chan struct{})}
+	key := batchKey{src, dest}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return b.Translator.TranslateContext(ctx, origin, src, dest)
+	}
+	p := b.pending[key]
+	if p != nil && p.chars+len(batchSeparator)+chars > b.config.MaxChars {
+		b.flushLocked(key)
+		p = nil
+	}
+	if p == nil {
+		p = &batch{}
+		p.timer = time.AfterFunc(b.config.Window, func() {
+			b.mu.Lock()
+			if b.pending[key] == p {
+				b.flushLocked(key)
+			}
+			b.mu.Unlock()
+		})
+		b.pending[key] = p
+	} else {
+		p.chars += len(batchSeparator)
+	}
+	p.calls = append(p.calls, call)
+	p.chars += chars
+	if len(p.calls) >= b.config.MaxItems {
+		b.flushLocked(key)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.result, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close sends the pending batches and waits for them. Later calls are
+// translated on their own. The Translator is not closed.
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	for key := range b.pending {
+		b.flushLocked(key)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+	return nil
+}
+
+// flushLocked sends the pending batch of key. b.mu must be held.
+func (b *Batcher) flushLocked(key batchKey) {
+	p := b.pending[key]
+	delete(b.pending, key)
+	p.timer.Stop()
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.send(key, p.calls)
+	}()
+}
+
+// send translates the calls of a batch and hands each caller its result.
+func (b *Batcher) send(key batchKey, calls []*batchCall) {
+	// Callers that already gave up are left out of the request.
+	live := calls[:0]
+	for _, call := range calls {
+		if call.ctx.Err() == nil {
+			live = append(live, call)
+		}
+	}
+	if len(live) == 0 {
+		return
+	}
+	if len(live) == 1 {
+		b.sendEach(key, live)
+		return
+	}
+
+	origins := make([]string, len(live))
+	for i, call := range live {
+		origins[i] = call.origin
+	}
+	// The request serves every caller, so it is detached from their cancellation.
+	ctx := context.WithoutCancel(live[0].ctx)
+	result, err := b.Translator.TranslateContext(ctx, strings.Join(origins, batchSeparator), key.src, key.dest)
+	if err != nil {
+		for _, call := range live {
+			call.err = err
+			close(call.done)
+		}
+		return
+	}
+
+	parts := strings.Split(strings.TrimRight(result.Text, batchSeparator), batchSeparator)
+	if len(parts) != len(live) {
+		// The translation merged or split lines; translate the texts one by one instead.
+		b.sendEach(key, live)
+		return
+	}
+	for i, call := range live {
+		// Spaces the service puts around line breaks are dropped; those of the text are kept.
+		lead, trail := surroundingSpace(call.origin)
+		text := lead + strings.TrimSpace(parts[i]) + trail
+		call.result = &Translated{Src: key.src, Dest: key.dest, Origin: call.origin, Text: text}
+		close(call.done)
+	}
+}
+
+// sendEach translates every call on its own, concurrently.
+func (b *Batcher) sendEach(key batchKey, calls []*batchCall) {
+	var wg sync.WaitGroup
+	for _, call := range calls {
+		wg.Add(1)
+		go func(call *batchCall) {
+			defer wg.Done()
+			call.result, call.err = b.Translator.TranslateContext(call.ctx, call.origin, key.src, key.dest)
+			close(call.done)
+		}(call)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,114 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// upperHandler translates by upper-casing the query, one sentence per line,
+// or into a single line when join is set.
+func upperHandler(hits *atomic.Int32, join bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		hits.Add(1)
+		q := strings.ToUpper(r.URL.Query().Get("q"))
+		if join {
+			q = strings.ReplaceAll(q, "\n", " ")
+		}
+		var s sentences
+		for _, line := range strings.SplitAfter(q, "\n") {
+			s.Sentences = append(s.Sentences, sentence{Trans: line, Orig: line})
+		}
+		json.NewEncoder(w).Encode(s)
+	})
+}
+
+func translateAll(t *testing.T, b *Batcher, texts []string, src string) []*Translated {
+	t.Helper()
+	results := make([]*Translated, len(texts))
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			var err error
+			if results[i], err = b.Translate(text, src, "es"); err != nil {
+				t.Error(err)
+			}
+		}(i, text)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestBatcher(t *testing.T) {
+	var hits atomic.Int32
+	b := NewBatcher(newTestTranslator(t, upperHandler(&hits, false)), BatcherConfig{MaxItems: 8})
+	defer b.Close()
+
+	var texts []string
+	for i := 0; i < 8; i++ {
+		texts = append(texts, fmt.Sprintf("text %d", i))
+	}
+	for i, r := range translateAll(t, b, texts, "en") {
+		if r.Origin != texts[i] || r.Text != strings.ToUpper(texts[i]) || r.Src != "en" {
+			t.Fatalf("result %d: %+v", i, r)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("%d upstream requests for one full batch, want 1", n)
+	}
+
+	hits.Store(0)
+	translateAll(t, b, []string{"a", "b"}, "auto")
+	translateAll(t, b, []string{"multi\nline"}, "en")
+	if n := hits.Load(); n != 3 {
+		t.Fatalf("%d upstream requests for calls that are not batched, want 3", n)
+	}
+}
+
+func TestBatcher_SplitMismatch(t *testing.T) {
+	var hits atomic.Int32
+	b := NewBatcher(newTestTranslator(t, upperHandler(&hits, true)))
+	defer b.Close()
+
+	texts := []string{"one", "two", "three"}
+	for i, r := range translateAll(t, b, texts, "en") {
+		if r.Text != strings.ToUpper(texts[i]) {
+			t.Fatalf("result %d: %+v", i, r)
+		}
+	}
+	if n := hits.Load(); n != 1+int32(len(texts)) {
+		t.Fatalf("%d upstream requests, want one batch and %d single calls", n, len(texts))
+	}
+}
+
+func TestBatcher_MatchesUnbatched(t *testing.T) {
+	var hits atomic.Int32
+	trans := newTestTranslator(t, upperHandler(&hits, false))
+	b := NewBatcher(trans, BatcherConfig{MaxItems: 4})
+	defer b.Close()
+
+	texts := []string{"  indented", "trailing  ", "tab\tinside", " both "}
+	batched := translateAll(t, b, texts, "en")
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("%d upstream requests, want one batch", n)
+	}
+	for i, text := range texts {
+		single, err := trans.Translate(text, "en", "es")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if batched[i].Text != single.Text {
+			t.Fatalf("text %q: batched %q, unbatched %q", text, batched[i].Text, single.Text)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package translator
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxBlockScan is the number of body bytes inspected when looking for block pages.
+const maxBlockScan = 64 * 1024
+
+// BlockKind identifies the kind of page Google served instead of the expected content.
+type BlockKind string
+
+const (
+	// BlockCaptcha is the "unusual traffic" page asking to solve a captcha.
+	BlockCaptcha BlockKind = "captcha"
+	// BlockConsent is the cookie consent page served to EU traffic.
+	BlockConsent BlockKind = "consent"
+)
+
+// BlockedError is returned when Google answers with a captcha or consent page
+// instead of the homepage or a translation. It matches ErrCaptcha or ErrConsent
+// with errors.Is and is never retryable as is: the host or proxy has to change first.
+type BlockedError struct {
+	Kind       BlockKind // kind of page that was served
+	Host       string    // host the request was sent to
+	StatusCode int       // status code of the block page
+	URL        string    // page the request ended up on, without its query string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("blocked by %s page from %s (status %d, %s)", e.Kind, e.Host, e.StatusCode, e.URL)
+}
+
+// Is reports whether the error matches one of the package sentinels.
+func (e *BlockedError) Is(target error) bool {
+	switch target {
+	case ErrCaptcha:
+		return e.Kind == BlockCaptcha
+	case ErrConsent:
+		return e.Kind == BlockConsent
+	}
+	return false
+}
+
+// Retryable always reports false; see BlockedError.
+func (e *BlockedError) Retryable() bool {
+	return false
+}
+
+var (
+	captchaMarkers = [][]byte{
+		[]byte("unusual traffic"),
+		[]byte("g-recaptcha"),
+		[]byte("captcha-form"),
+		[]byte("/sorry/index"),
+	}
+	// Regular pages link to consent.google.com as well, so consent pages are
+	// mostly recognised by the redirect to that host.
+	consentMarkers = [][]byte{
+		[]byte("before you continue to google"),
+	}
+)
+
+// checkBlocked returns a *BlockedError when resp, whose body starts with body,
+// is a captcha or consent page rather than the content that was requested.
+// JSON bodies are never considered block pages.
+func checkBlocked(host string, resp *http.Response, body []byte) error {
+	final := resp.Request.URL
+	kind := blockKindOfURL(final)
+	if kind == "" {
+		kind = blockKindOfBody(body)
+	}
+	if kind == "" {
+		return nil
+	}
+
+	page := url.URL{Scheme: final.Scheme, Host: final.Host, Path: final.Path}
+	return &BlockedError{
+		Kind:       kind,
+		Host:       host,
+		StatusCode: resp.StatusCode,
+		URL:        page.String(),
+	}
+}
+
+// blockKindOfURL recognises block pages from the URL a request was redirected to.
+func blockKindOfURL(u *url.URL) BlockKind {
+	switch {
+	case strings.HasPrefix(u.Path, "/sorry"):
+		return BlockCaptcha
+	case strings.HasPrefix(u.Host, "consent."):
+		return BlockConsent
+	}
+	return ""
+}
+
+// blockKindOfBody recognises block pages served without a redirect.
+func blockKindOfBody(body []byte) BlockKind {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] == '{' || trimmed[0] == '[' {
+		return ""
+	}
+	if len(trimmed) > maxBlockScan {
+		trimmed = trimmed[:maxBlockScan]
+	}
+	lower := bytes.ToLower(trimmed)
+	for _, m := range captchaMarkers {
+		if bytes.Contains(lower, m) {
+			return BlockCaptcha
+		}
+	}
+	for _, m := range consentMarkers {
+		if bytes.Contains(lower, m) {
+			return BlockConsent
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package translator
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const sorryPage = `<html><body>Our systems have detected unusual traffic from your computer network.
+<div class="g-recaptcha"></div></body></html>`
+
+func TestTranslate_CaptchaWithStatus200(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/translate_a/single" {
+			w.Write([]byte(sorryPage))
+		}
+	}))
+
+	_, err := trans.Translate("hello", "en", "es")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.Kind != BlockCaptcha || blocked.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !errors.Is(err, ErrCaptcha) || errors.Is(err, ErrConsent) || IsRetryable(err) {
+		t.Fatalf("error %v is misclassified", err)
+	}
+}
+
+func TestTranslate_HomepageRedirectedToSorry(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/sorry/index?continue=secret", http.StatusFound)
+		case "/sorry/index":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(sorryPage))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+
+	_, err := trans.DetectLanguage("hello", "en")
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || blocked.Kind != BlockCaptcha || blocked.URL != "https://"+trans.GetHost()+"/sorry/index" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestTranslate_ConsentRedirect(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/translate_a/single" {
+			// The redirect target cannot be resolved in tests, so serve the consent page directly.
+			w.Write([]byte("<html><title>Before you continue to Google</title></html>"))
+		}
+	}))
+
+	_, err := trans.Translate("hello", "en", "es")
+	if !errors.Is(err, ErrConsent) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestTranslate_OnBlockedSwitchesHost(t *testing.T) {
+	good := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/translate_a/single" {
+			w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+		}
+	}))
+	defer good.Close()
+
+	blocked := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sorryPage))
+	}))
+	defer blocked.Close()
+
+	calls := 0
+	// Both servers use the same httptest certificate.
+	roots := x509.NewCertPool()
+	roots.AddCert(good.Certificate())
+	trans := New(Config{
+		ServiceUrls: []string{blocked.Listener.Addr().String()},
+		RootCAs:     roots,
+		OnBlocked: func(t *Translator, err *BlockedError) bool {
+			calls++
+			t.SetHost(good.Listener.Addr().String())
+			return true
+		},
+	})
+
+	result, err := trans.Translate("hello", "en", "es")
+	if err != nil || result.Text != "hola" {
+		t.Fatalf("got %v, %v", result, err)
+	}
+	if calls != 1 || trans.GetHost() != good.Listener.Addr().String() {
+		t.Fatalf("hook called %d times, host %s", calls, trans.GetHost())
+	}
+}
+
+func TestBlockKindOfBody_IgnoresJSON(t *testing.T) {
+	if kind := blockKindOfBody([]byte(`{"sentences":[{"trans":"unusual traffic"}]}`)); kind != "" {
+		t.Fatalf("JSON body detected as %s", kind)
+	}
+}
@@ -0,0 +1,199 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerCoolDown    = 30 * time.Second
+	defaultBreakerHalfOpenMax = 1
+)
+
+// BreakerConfig configures the circuit breaker kept per host and operation.
+type BreakerConfig struct {
+	// Failures is the number of consecutive failures that open a circuit. Zero disables the breaker.
+	Failures int
+	// CoolDown is how long a circuit stays open before trial requests are let through. Defaults to 30 seconds.
+	CoolDown time.Duration
+	// HalfOpenMax is the number of trial requests allowed at the same time while half-open. Defaults to 1.
+	HalfOpenMax int
+}
+
+// BreakerState is the state of a circuit.
+type BreakerState string
+
+const (
+	// BreakerClosed lets requests through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails requests at once with ErrCircuitOpen until the cool-down has passed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets trial requests through: a success closes the circuit, a failure opens it again.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerStatus describes the circuit of a host and operation.
+type BreakerStatus struct {
+	Host      string
+	Operation Operation
+	State     BreakerState
+	Failures  int       // consecutive failures
+	OpenedAt  time.Time // when the circuit last opened, zero if it never did
+}
+
+type breakerKey struct {
+	host string
+	op   Operation
+}
+
+type circuit struct {
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	trials   int // trial requests in flight while half-open
+}
+
+// breaker keeps a circuit per host and operation. A nil *breaker lets every request through.
+type breaker struct {
+	mu       sync.Mutex
+	config   BreakerConfig
+	circuits map[breakerKey]*circuit
+	logger   *slog.Logger
+}
+
+func newBreaker(c BreakerConfig, logger *slog.Logger) *breaker {
+	if c.Failures <= 0 {
+		return nil
+	}
+	if c.CoolDown <= 0 {
+		c.CoolDown = defaultBreakerCoolDown
+	}
+	if c.HalfOpenMax <= 0 {
+		c.HalfOpenMax = defaultBreakerHalfOpenMax
+	}
+	return &breaker{config: c, circuits: map[breakerKey]*circuit{}, logger: logger}
+}
+
+// allow reports whether a request to host for op may be sent, and whether it
+// is a trial request of a half-open circuit.
+func (b *breaker) allow(host string, op Operation) (trial bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(host, op)
+	if c.state == BreakerOpen && time.Since(c.openedAt) >= b.config.CoolDown {
+		c.state = BreakerHalfOpen
+	}
+	switch c.state {
+	case BreakerOpen:
+		return false, fmt.Errorf("%w for %s on %s", ErrCircuitOpen, op, host)
+	case BreakerHalfOpen:
+		if c.trials >= b.config.HalfOpenMax {
+			return false, fmt.Errorf("%w for %s on %s", ErrCircuitOpen, op, host)
+		}
+		c.trials++
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates the circuit of host and op with the outcome of a request let
+// through by allow. Only a response closes the circuit and only a failure of
+// the host counts against it; other errors, such as client errors or block
+// pages, just release the trial slot. While the circuit is not closed, only
+// trial requests change it: a request let through before it opened says
+// nothing about the host now.
+func (b *breaker) record(ctx context.Context, host string, op Operation, trial bool, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	c := b.circuit(host, op)
+	if trial {
+		c.trials--
+	}
+	previous := c.state
+	switch {
+	case !trial && c.state != BreakerClosed:
+	case err == nil:
+		c.failures = 0
+		c.state = BreakerClosed
+	case errors.Is(err, context.Canceled):
+		// The caller gave up; this says nothing about the host.
+	case breakerFailure(err):
+		c.failures++
+		if c.state == BreakerHalfOpen || c.failures >= b.config.Failures {
+			c.state = BreakerOpen
+			c.openedAt = time.Now()
+		}
+	}
+	state, failures := c.state, c.failures
+	b.mu.Unlock()
+
+	if state != previous {
+		level := slog.LevelInfo
+		if state == BreakerOpen {
+			level = slog.LevelWarn
+		}
+		b.logger.LogAttrs(ctx, level, "circuit "+string(state), slog.String("host", host),
+			slog.String("operation", string(op)), slog.Int("failures", failures))
+	}
+}
+
+func (b *breaker) circuit(host string, op Operation) *circuit {
+	key := breakerKey{host, op}
+	c := b.circuits[key]
+	if c == nil {
+		c = &circuit{state: BreakerClosed}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+func (b *breaker) status() []BreakerStatus {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := make([]BreakerStatus, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		state := c.state
+		if state == BreakerOpen && time.Since(c.openedAt) >= b.config.CoolDown {
+			state = BreakerHalfOpen
+		}
+		status = append(status, BreakerStatus{Host: key.host, Operation: key.op, State: state, Failures: c.failures, OpenedAt: c.openedAt})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].Host != status[j].Host {
+			return status[i].Host < status[j].Host
+		}
+		return status[i].Operation < status[j].Operation
+	})
+	return status
+}
+
+// breakerFailure reports whether err shows that the host is failing: a
+// connection error, a timeout, a throttled request or a server error.
+func breakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &urlErr) || IsRetryable(err)
+}
+
+// BreakerStatus returns the state of every circuit, by host and operation.
+// It is empty unless Config.Breaker is set.
+func (a *Translator) BreakerStatus() []BreakerStatus {
+	return a.up.breaker.status()
+}
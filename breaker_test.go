@@ -0,0 +1,149 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker_States(t *testing.T) {
+	ctx := context.Background()
+	b := newBreaker(BreakerConfig{Failures: 2, CoolDown: time.Hour}, newLogger(nil))
+	fail := &HTTPError{StatusCode: http.StatusServiceUnavailable}
+
+	for i := 0; i < 2; i++ {
+		trial, err := b.allow("h", OpTranslate)
+		if err != nil || trial {
+			t.Fatalf("closed circuit: trial %v, err %v", trial, err)
+		}
+		b.record(ctx, "h", OpTranslate, trial, fail)
+	}
+	if _, err := b.allow("h", OpTranslate); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open circuit: got %v", err)
+	}
+	if _, err := b.allow("h", OpDetect); err != nil {
+		t.Fatalf("other operation: got %v", err)
+	}
+	// Client errors and cancellations do not count as failures.
+	b.record(ctx, "h", OpDetect, false, &HTTPError{StatusCode: http.StatusBadRequest})
+	b.record(ctx, "h", OpDetect, false, context.Canceled)
+
+	b.circuits[breakerKey{"h", OpTranslate}].openedAt = time.Now().Add(-time.Hour)
+	trial, err := b.allow("h", OpTranslate)
+	if err != nil || !trial {
+		t.Fatalf("half-open circuit: trial %v, err %v", trial, err)
+	}
+	if _, err := b.allow("h", OpTranslate); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second trial: got %v", err)
+	}
+	b.record(ctx, "h", OpTranslate, true, fail)
+	if _, err := b.allow("h", OpTranslate); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("failed trial did not reopen the circuit: %v", err)
+	}
+
+	// Neither a block page nor a late request let through while closed
+	// closes a half-open circuit; the trial slot is released.
+	b.circuits[breakerKey{"h", OpTranslate}].openedAt = time.Now().Add(-time.Hour)
+	trial, _ = b.allow("h", OpTranslate)
+	b.record(ctx, "h", OpTranslate, trial, &BlockedError{Kind: BlockCaptcha})
+	b.record(ctx, "h", OpTranslate, false, nil)
+	if s := b.status()[1]; s.State != BreakerHalfOpen || s.Failures != 3 {
+		t.Fatalf("half-open circuit changed: %+v", s)
+	}
+	trial, err = b.allow("h", OpTranslate)
+	if err != nil || !trial {
+		t.Fatalf("trial slot not released: trial %v, err %v", trial, err)
+	}
+	b.record(ctx, "h", OpTranslate, trial, nil)
+	status := b.status()
+	if len(status) != 2 || status[0].Operation != OpDetect || status[0].Failures != 0 ||
+		status[1].State != BreakerClosed || status[1].OpenedAt.IsZero() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestTranslate_CircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	var hits atomic.Int32
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		hits.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	}), Config{Breaker: BreakerConfig{Failures: 2, CoolDown: 20 * time.Millisecond}})
+
+	for i := 0; i < 2; i++ {
+		if _, err := trans.Translate("hello", "en", "es"); !errors.Is(err, ErrUnexpectedResponse) {
+			t.Fatalf("call %d: got %v", i, err)
+		}
+	}
+	if _, err := trans.Translate("hello", "en", "es"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open circuit: got %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("%d requests reached the host, want 2", hits.Load())
+	}
+
+	healthy.Store(true)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := trans.Translate("hello", "en", "es"); err != nil {
+		t.Fatalf("after cool-down: %v", err)
+	}
+	for _, s := range trans.BreakerStatus() {
+		if s.State != BreakerClosed {
+			t.Fatalf("unexpected status %+v", s)
+		}
+	}
+}
+
+func TestTranslate_CircuitBreakerTokenRefresh(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	}), Config{Breaker: BreakerConfig{Failures: 2, CoolDown: time.Hour}})
+
+	// The failing homepage opens the token_refresh circuit, which must not fail
+	// translations that do not need a TKK.
+	for i := 0; i < 4; i++ {
+		if _, err := trans.Translate("hello", "en", "es"); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+}
+
+func TestTranslate_CircuitBreakerNoProxy(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	}), Config{Proxies: []string{"http://127.0.0.1:1"}, Breaker: BreakerConfig{Failures: 1, CoolDown: time.Hour}})
+	trans.up.proxies.Load().proxies[0].evictedUntil = time.Now().Add(time.Hour)
+	host := trans.GetHost()
+	for _, op := range []Operation{OpTokenRefresh, OpTranslate} {
+		trans.up.breaker.circuits[breakerKey{host, op}] = &circuit{state: BreakerOpen, failures: 1, openedAt: time.Now().Add(-time.Hour)}
+	}
+
+	if _, err := trans.Translate("hello", "en", "es"); !errors.Is(err, ErrNoProxy) {
+		t.Fatalf("got %v, want ErrNoProxy", err)
+	}
+	for _, s := range trans.BreakerStatus() {
+		if s.State != BreakerHalfOpen || s.Failures != 1 {
+			t.Fatalf("half-open circuit changed without a request: %+v", s)
+		}
+	}
+	for _, c := range trans.up.breaker.circuits {
+		if c.trials != 0 {
+			t.Fatalf("%d trial slots taken without a request", c.trials)
+		}
+	}
+}
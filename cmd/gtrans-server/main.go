@@ -0,0 +1,152 @@
+// Command gtrans-server serves the translator library over HTTP.
+//
+// See the server package for the endpoints. On SIGINT or SIGTERM the server
+// reports itself as not ready, waits -drain for load balancers to notice,
+// then stops accepting connections and lets in-flight requests finish within
+// -shutdown-timeout.
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+	"github.com/lcapuano-app/go-googletrans/server"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Printf("gtrans-server: %v", err)
+		os.Exit(1)
+	}
+}
+
+// run serves until a signal or a server failure. It returns errors rather than
+// exiting so that the deferred Close calls run.
+func run() error {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	proxies := flag.String("proxy", "", "comma separated http, https or socks5 proxy URLs for upstream requests, rotated round-robin")
+	hosts := flag.String("hosts", "", "comma separated service hosts, defaults to the library's list")
+	concurrency := flag.Int("concurrency", 8, "maximum upstream requests in flight")
+	maxBatch := flag.Int("max-batch", 100, "maximum texts per batch request")
+	maxText := flag.Int("max-text", 5000, "maximum characters per text")
+	libre := flag.Bool("libretranslate", false, "also serve the LibreTranslate compatible API")
+	drain := flag.Duration("drain", 0, "time to report not ready before shutting down")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "time allowed for in-flight requests on shutdown")
+	caFile := flag.String("cacert", "", "PEM file of root certificates to trust for upstream requests instead of the system roots")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification of upstream requests (debugging only)")
+	cookieFile := flag.String("cookies", "", "file to keep upstream cookies in across restarts, which also accepts consent pages")
+	breakerFailures := flag.Int("breaker-failures", 0, "consecutive upstream failures that open the circuit of a host, 0 to disable")
+	breakerCoolDown := flag.Duration("breaker-cooldown", 30*time.Second, "time an open circuit waits before trial requests")
+	batchWindow := flag.Duration("batch-window", 0, "gather translations for the same language pair arriving within this window into one upstream request, 0 to disable")
+	coalesce := flag.Bool("coalesce", false, "share one upstream request between identical concurrent translations")
+	probeInterval := flag.Duration("probe-interval", 0, "probe the hosts at this interval and use the best one, 0 to pick one at random")
+	flag.Parse()
+
+	serviceUrls := splitList(*hosts)
+	proxyUrls := splitList(*proxies)
+
+	var roots *x509.CertPool
+	if *caFile != "" {
+		pem, err := os.ReadFile(*caFile)
+		if err != nil {
+			return err
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", *caFile)
+		}
+	}
+
+	var jar http.CookieJar
+	if *cookieFile != "" {
+		fileJar, err := translator.NewFileJar(*cookieFile)
+		if err != nil {
+			return err
+		}
+		defer fileJar.Close()
+		jar = fileJar
+	}
+
+	t := translator.New(translator.Config{
+		ServiceUrls:        serviceUrls,
+		Proxies:            proxyUrls,
+		RootCAs:            roots,
+		InsecureSkipVerify: *insecure,
+		CookieJar:          jar,
+		ProbeInterval:      *probeInterval,
+		CoalesceRequests:   *coalesce,
+		Breaker:            translator.BreakerConfig{Failures: *breakerFailures, CoolDown: *breakerCoolDown},
+		Logger:             slog.Default(),
+	})
+	defer t.Close()
+	var backend server.Backend = t
+	if *batchWindow > 0 {
+		batcher := translator.NewBatcher(t, translator.BatcherConfig{Window: *batchWindow})
+		defer batcher.Close()
+		backend = batcher
+	}
+	handler := server.New(backend, server.Config{
+		MaxConcurrency: *concurrency,
+		MaxBatchSize:   *maxBatch,
+		MaxTextLength:  *maxText,
+		LibreTranslate: *libre,
+	})
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("gtrans-server listening on %s", *addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("gtrans-server shutting down")
+	handler.SetReady(false)
+	time.Sleep(*drain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown: %v", err)
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
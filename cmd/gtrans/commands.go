@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+	"github.com/lcapuano-app/go-googletrans/gettext"
+	"github.com/lcapuano-app/go-googletrans/i18n"
+)
+
+// translation is the JSON representation of a translated item.
+type translation struct {
+	Src    string `json:"src"`
+	Dest   string `json:"dest"`
+	Origin string `json:"origin"`
+	Text   string `json:"text"`
+}
+
+// detection is the JSON representation of a detected item.
+type detection struct {
+	Text       string  `json:"text"`
+	Src        string  `json:"src"`
+	Confidence float64 `json:"confidence"`
+}
+
+func runTranslate(opts *options, args []string, stdin io.Reader, stdout io.Writer) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if opts.doc {
+		return translateDocument(opts, args, stdin, stdout)
+	}
+	inputs, err := readInputs(opts, args, stdin)
+	if err != nil {
+		return err
+	}
+
+	t := newTranslator(opts)
+	enc := json.NewEncoder(stdout)
+	for _, text := range inputs {
+		if strings.TrimSpace(text) == "" {
+			if opts.format == "plain" {
+				fmt.Fprintln(stdout)
+			}
+			continue
+		}
+		result, err := t.Translate(text, opts.src, opts.dest)
+		if err != nil {
+			return err
+		}
+		switch opts.format {
+		case "json":
+			err = enc.Encode(translation{result.Src, result.Dest, result.Origin, result.Text})
+		case "tsv":
+			_, err = fmt.Fprintln(stdout, tsvRow(result.Src, result.Dest, result.Origin, result.Text))
+		default:
+			_, err = fmt.Fprintln(stdout, result.Text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translateDocument streams the arguments, or else the -f files one after the
+// other, or else stdin, through TranslateReader.
+func translateDocument(opts *options, args []string, stdin io.Reader, stdout io.Writer) error {
+	if opts.format != "plain" {
+		return usagef("-doc: only the plain format is supported")
+	}
+	t := newTranslator(opts)
+	ctx := context.Background()
+	if len(args) > 0 {
+		if err := t.TranslateReader(ctx, strings.NewReader(strings.Join(args, " ")), stdout, opts.src, opts.dest); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(stdout)
+		return err
+	}
+	if len(opts.files) == 0 {
+		return t.TranslateReader(ctx, stdin, stdout, opts.src, opts.dest)
+	}
+	for _, name := range opts.files {
+		if name == "-" {
+			if err := t.TranslateReader(ctx, stdin, stdout, opts.src, opts.dest); err != nil {
+				return err
+			}
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return usagef("%v", err)
+		}
+		err = t.TranslateReader(ctx, f, stdout, opts.src, opts.dest)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runDetect(opts *options, args []string, stdin io.Reader, stdout io.Writer) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	inputs, err := readInputs(opts, args, stdin)
+	if err != nil {
+		return err
+	}
+
+	t := newTranslator(opts)
+	enc := json.NewEncoder(stdout)
+	for _, text := range inputs {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		result, err := t.DetectLanguage(text, opts.dest)
+		if err != nil {
+			return err
+		}
+		switch opts.format {
+		case "json":
+			err = enc.Encode(detection{text, result.Src, result.Confidence})
+		case "tsv":
+			_, err = fmt.Fprintln(stdout, tsvRow(text, result.Src, fmt.Sprintf("%.4f", result.Confidence)))
+		default:
+			_, err = fmt.Fprintf(stdout, "%s\t%.2f\n", result.Src, result.Confidence)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runLanguages(opts *options, args []string, _ io.Reader, stdout io.Writer) error {
+	if len(args) > 0 {
+		return usagef("languages takes no arguments")
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	languages := newTranslator(opts).GetAvaliableLanguages()
+	if opts.format == "json" {
+		return json.NewEncoder(stdout).Encode(languages)
+	}
+
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	if opts.format == "tsv" {
+		for _, code := range codes {
+			if _, err := fmt.Fprintln(stdout, tsvRow(code, languages[code])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, code := range codes {
+		fmt.Fprintf(tw, "%s\t%s\n", code, languages[code])
+	}
+	return tw.Flush()
+}
+
+// probe is the JSON representation of a probed host.
+type probe struct {
+	Host      string  `json:"host"`
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latency_ms"`
+	Homepage  bool    `json:"homepage"`
+	TKK       bool    `json:"tkk"`
+	Translate bool    `json:"translate"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func runProbe(opts *options, args []string, _ io.Reader, stdout io.Writer) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	hosts := append(append([]string{}, opts.hosts...), args...)
+	results := translator.ProbeHosts(context.Background(), hosts, newConfig(opts))
+
+	enc := json.NewEncoder(stdout)
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	if opts.format == "plain" {
+		fmt.Fprintln(tw, "HOST\tSTATUS\tLATENCY\tHOMEPAGE\tTKK\tTRANSLATE\tERROR")
+	}
+	for _, r := range results {
+		p := probe{r.Host, r.Healthy(), float64(r.Latency.Microseconds()) / 1000, r.Homepage, r.TKK, r.Translate, ""}
+		if r.Err != nil {
+			p.Error = r.Err.Error()
+		}
+		status := "ok"
+		if !p.Healthy {
+			status = "failed"
+		}
+		var err error
+		switch opts.format {
+		case "json":
+			err = enc.Encode(p)
+		case "tsv":
+			_, err = fmt.Fprintln(stdout, tsvRow(p.Host, status, fmt.Sprintf("%.1f", p.LatencyMs),
+				fmt.Sprint(p.Homepage), fmt.Sprint(p.TKK), fmt.Sprint(p.Translate), p.Error))
+		default:
+			_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%t\t%t\t%s\n", p.Host, status,
+				r.Latency.Round(time.Millisecond), p.Homepage, p.TKK, p.Translate, p.Error)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// runPO translates the untranslated messages of a PO or POT file, the
+// argument or else stdin, and writes the PO file to -o or else stdout.
+func runPO(opts *options, args []string, stdin io.Reader, stdout io.Writer) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return usagef("po: expected one file, got %d", len(args))
+	}
+	in := stdin
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return usagef("%v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	file, err := gettext.Parse(in)
+	if err != nil {
+		return err
+	}
+	if _, err := gettext.Translate(context.Background(), newTranslator(opts), file, opts.src, opts.dest, gettext.Options{NoFuzzy: opts.noFuzzy}); err != nil {
+		return err
+	}
+
+	if opts.output == "" {
+		_, err = file.WriteTo(stdout)
+		return err
+	}
+	out, err := os.Create(opts.output)
+	if err != nil {
+		return usagef("-o: %v", err)
+	}
+	if _, err = file.WriteTo(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// runI18n translates a JSON locale file, the argument or else stdin, keeping
+// the messages of the -target file, and writes it to -o or else stdout.
+func runI18n(opts *options, args []string, stdin io.Reader, stdout io.Writer) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return usagef("i18n: expected one file, got %d", len(args))
+	}
+	in := stdin
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return usagef("%v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	bundle, err := i18n.Parse(in)
+	if err != nil {
+		return err
+	}
+
+	// A missing target file is the first translation into the language.
+	var existing *i18n.Bundle
+	if opts.target != "" {
+		f, err := os.Open(opts.target)
+		switch {
+		case err == nil:
+			existing, err = i18n.Parse(f)
+			f.Close()
+			if err != nil {
+				return usagef("-target: %v", err)
+			}
+		case !os.IsNotExist(err):
+			return usagef("-target: %v", err)
+		}
+	}
+	if _, err := i18n.Translate(context.Background(), newTranslator(opts), bundle, existing, opts.src, opts.dest); err != nil {
+		return err
+	}
+
+	if opts.output == "" {
+		_, err = bundle.WriteTo(stdout)
+		return err
+	}
+	out, err := os.Create(opts.output)
+	if err != nil {
+		return usagef("-o: %v", err)
+	}
+	if _, err = bundle.WriteTo(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// readInputs returns the items to process: the arguments joined into a single
+// item, or else every line of the -f files, or else every line of stdin.
+func readInputs(opts *options, args []string, stdin io.Reader) ([]string, error) {
+	if len(args) > 0 {
+		return []string{strings.Join(args, " ")}, nil
+	}
+	if len(opts.files) == 0 {
+		return readLines(stdin)
+	}
+
+	var inputs []string
+	for _, name := range opts.files {
+		if name == "-" {
+			lines, err := readLines(stdin)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, lines...)
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, usagef("%v", err)
+		}
+		lines, err := readLines(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, lines...)
+	}
+	return inputs, nil
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// tsvRow joins fields with tabs, escaping characters that would break the row.
+func tsvRow(fields ...string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+	escaped := make([]string, len(fields))
+	for i, f := range fields {
+		escaped[i] = escaper.Replace(f)
+	}
+	return strings.Join(escaped, "\t")
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+func TestRun_UsageErrors(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"unknown"},
+		{"translate", "-dest", "klingon", "hello"},
+		{"translate", "-dest", "auto", "hello"},
+		{"translate", "-format", "xml", "hello"},
+		{"detect", "-bogus"},
+		{"languages", "extra"},
+	}
+	for _, args := range cases {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code != exitUsage {
+			t.Errorf("run(%q) = %d, want %d; stderr: %s", args, code, exitUsage, stderr.String())
+		}
+	}
+}
+
+func TestRun_Languages(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"languages", "-format", "tsv"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "es\tspanish\n") {
+		t.Fatalf("missing spanish in output:\n%s", stdout.String())
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("boom"), exitFailure},
+		{usagef("bad"), exitUsage},
+		{&translator.HTTPError{StatusCode: 429, Host: "translate.google.com"}, exitRateLimited},
+		{&translator.HTTPError{StatusCode: 500, Host: "translate.google.com"}, exitFailure},
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("refused")}, exitNetwork},
+	}
+	for _, c := range cases {
+		if got := exitCode(c.err); got != c.want {
+			t.Errorf("exitCode(%v) = %d, want %d", c.err, got, c.want)
+		}
+	}
+}
+
+func TestTsvRow(t *testing.T) {
+	got := tsvRow("a\tb", "c\nd", `e\f`)
+	want := `a\tb` + "\t" + `c\nd` + "\t" + `e\\f`
+	if got != want {
+		t.Fatalf("tsvRow = %q, want %q", got, want)
+	}
+}
+
+func TestRun_Probe(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	}))
+	defer srv.Close()
+	host := srv.Listener.Addr().String()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"probe", "-insecure", "-format", "json", host}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	var p probe
+	if err := json.Unmarshal(stdout.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Host != host || !p.Healthy || p.TKK || p.Error != "" {
+		t.Fatalf("unexpected probe %+v", p)
+	}
+}
+
+func TestRun_TranslateDoc(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		q := strings.ToUpper(r.URL.Query().Get("q"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"sentences": []map[string]string{{"trans": q, "orig": q}}})
+	}))
+	defer srv.Close()
+
+	input := "First paragraph.\nStill the first.\n\nSecond paragraph.\n"
+	var stdout, stderr bytes.Buffer
+	args := []string{"translate", "-doc", "-insecure", "-host", srv.Listener.Addr().String(), "-src", "en", "-dest", "es"}
+	if code := run(args, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if stdout.String() != strings.ToUpper(input) {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+
+	if code := run([]string{"translate", "-doc", "-format", "json"}, strings.NewReader(input), &stdout, &stderr); code != exitUsage {
+		t.Fatalf("exit code %d for -doc with json, want %d", code, exitUsage)
+	}
+}
+
+func TestRun_PO(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		q := strings.ToUpper(r.URL.Query().Get("q"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"sentences": []map[string]string{{"trans": q, "orig": q}}})
+	}))
+	defer srv.Close()
+
+	input := "#: main.c:1\nmsgid \"Hello\"\nmsgstr \"\"\n"
+	out := filepath.Join(t.TempDir(), "de.po")
+	var stdout, stderr bytes.Buffer
+	args := []string{"po", "-insecure", "-host", srv.Listener.Addr().String(), "-src", "en", "-dest", "de", "-o", out}
+	if code := run(args, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "msgid \"\"\nmsgstr \"\"\n\"MIME-Version: 1.0\\n\"\n\"Content-Type: text/plain; charset=UTF-8\\n\"\n\"Content-Transfer-Encoding: 8bit\\n\"\n" +
+		"\"Plural-Forms: nplurals=2; plural=(n != 1);\\n\"\n\"Language: de\\n\"\n\n" +
+		"# Machine translated from en to de\n#: main.c:1\n#, fuzzy\nmsgid \"Hello\"\nmsgstr \"HELLO\"\n"
+	if string(data) != want {
+		t.Fatalf("unexpected output:\n%s", data)
+	}
+
+	if code := run([]string{"po", "a.po", "b.po"}, nil, &stdout, &stderr); code != exitUsage {
+		t.Fatalf("exit code %d for two files, want %d", code, exitUsage)
+	}
+}
+
+func TestRun_I18n(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		q := strings.ToUpper(r.URL.Query().Get("q"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"sentences": []map[string]string{{"trans": q, "orig": q}}})
+	}))
+	defer srv.Close()
+
+	target := filepath.Join(t.TempDir(), "de.json")
+	if err := os.WriteFile(target, []byte(`{"b": "Behalten"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	input := "{\n  \"a\": \"hello\",\n  \"b\": \"keep\"\n}\n"
+	var stdout, stderr bytes.Buffer
+	args := []string{"i18n", "-insecure", "-host", srv.Listener.Addr().String(), "-src", "en", "-dest", "de", "-target", target, "-o", target}
+	if code := run(args, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"a\": \"HELLO\",\n  \"b\": \"Behalten\"\n}\n"; string(data) != want {
+		t.Fatalf("unexpected output:\n%s", data)
+	}
+}
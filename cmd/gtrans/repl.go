@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+// maxHistory bounds the number of entries kept in memory and on disk.
+const maxHistory = 1000
+
+const replHelp = `type text to translate it, or one of:
+  :src [lang]     show or set the source language
+  :dest [lang]    show or set the destination language
+  :swap           swap source and destination languages
+  :detect <text>  detect the language of text
+  :history [n]    show the last n entries (default 20)
+  :help           show this help
+  :quit           leave the shell
+`
+
+// repl is an interactive session around a single Translator.
+type repl struct {
+	t       *translator.Translator
+	src     string
+	dest    string
+	out     io.Writer
+	history []string
+	file    *os.File
+
+	// lastDetected is the language detected for the last auto translation,
+	// used by :swap when the source language is auto.
+	lastDetected string
+}
+
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gtrans_history")
+}
+
+func runREPL(opts *options, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) > 0 {
+		return usagef("repl takes no arguments")
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	r := &repl{
+		t:    newTranslator(opts),
+		src:  opts.src,
+		dest: opts.dest,
+		out:  stdout,
+	}
+	if opts.history != "" {
+		if err := r.openHistory(opts.history); err != nil {
+			return err
+		}
+		defer r.file.Close()
+	}
+
+	fmt.Fprintln(r.out, `gtrans interactive mode, type :help for commands`)
+	scanner := bufio.NewScanner(stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for {
+		fmt.Fprintf(r.out, "[%s>%s] ", r.src, r.dest)
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		r.record(line)
+		if line == ":quit" || line == ":q" || line == ":exit" {
+			return nil
+		}
+		if err := r.exec(line); err != nil {
+			fmt.Fprintf(r.out, "error: %v\n", err)
+		}
+	}
+	fmt.Fprintln(r.out)
+	return scanner.Err()
+}
+
+// exec runs a single line of input.
+func (r *repl) exec(line string) error {
+	if !strings.HasPrefix(line, ":") {
+		return r.translate(line)
+	}
+
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case ":src":
+		return r.setLanguage(&r.src, arg, true)
+	case ":dest":
+		return r.setLanguage(&r.dest, arg, false)
+	case ":swap":
+		src := r.src
+		if src == "auto" {
+			if r.lastDetected == "" {
+				return fmt.Errorf("cannot swap an auto source before anything was detected")
+			}
+			src = r.lastDetected
+		}
+		r.src, r.dest = r.dest, src
+		return nil
+	case ":detect":
+		if arg == "" {
+			return fmt.Errorf("usage: :detect <text>")
+		}
+		return r.detect(arg)
+	case ":history":
+		n := 20
+		if arg != "" {
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil || n < 1 {
+				return fmt.Errorf("usage: :history [n]")
+			}
+		}
+		r.printHistory(n)
+		return nil
+	case ":help", ":h":
+		fmt.Fprint(r.out, replHelp)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %s, type :help for commands", cmd)
+	}
+}
+
+func (r *repl) setLanguage(target *string, arg string, allowAuto bool) error {
+	if arg == "" {
+		fmt.Fprintln(r.out, *target)
+		return nil
+	}
+	lang, err := translator.GetValidLanguageKey(arg)
+	if err != nil {
+		return err
+	}
+	if lang == "auto" && !allowAuto {
+		return fmt.Errorf("destination language cannot be auto")
+	}
+	*target = lang
+	return nil
+}
+
+// translate translates text, going through language detection when the source
+// is auto so that the detected language can be shown alongside the result.
+func (r *repl) translate(text string) error {
+	if r.src != "auto" {
+		result, err := r.t.Translate(text, r.src, r.dest)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, result.Text)
+		return nil
+	}
+
+	detected, err := r.t.DetectLanguage(text, r.dest)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, s := range detected.Sentences {
+		sb.WriteString(s.Trans)
+	}
+	r.lastDetected = detected.Src
+	fmt.Fprintln(r.out, sb.String())
+	fmt.Fprintf(r.out, "  (detected %s, confidence %.2f)\n", detected.Src, detected.Confidence)
+	return nil
+}
+
+func (r *repl) detect(text string) error {
+	detected, err := r.t.DetectLanguage(text, r.dest)
+	if err != nil {
+		return err
+	}
+	r.lastDetected = detected.Src
+	fmt.Fprintf(r.out, "%s\t%.2f\t%s\n", detected.Src, detected.Confidence, r.languageName(detected.Src))
+
+	ld := detected.LdResult
+	for i, lang := range ld.Srclangs {
+		if lang == detected.Src {
+			continue
+		}
+		confidence := 0.0
+		if i < len(ld.SrclangsConfidences) {
+			confidence = ld.SrclangsConfidences[i]
+		}
+		fmt.Fprintf(r.out, "  alt %s\t%.2f\t%s\n", lang, confidence, r.languageName(lang))
+	}
+	return nil
+}
+
+func (r *repl) languageName(code string) string {
+	return r.t.GetAvaliableLanguages()[code]
+}
+
+// openHistory loads previous entries from path and keeps it open for appending.
+func (r *repl) openHistory(path string) error {
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				r.history = append(r.history, line)
+			}
+		}
+		if len(r.history) > maxHistory {
+			r.history = r.history[len(r.history)-maxHistory:]
+			// Rewrite the file so it does not grow without bound.
+			content := strings.Join(r.history, "\n") + "\n"
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				return err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	return nil
+}
+
+func (r *repl) record(line string) {
+	r.history = append(r.history, line)
+	if len(r.history) > maxHistory {
+		r.history = r.history[1:]
+	}
+	if r.file != nil {
+		fmt.Fprintln(r.file, line)
+	}
+}
+
+func (r *repl) printHistory(n int) {
+	start := len(r.history) - n
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(r.history); i++ {
+		fmt.Fprintf(r.out, "%5d  %s\n", i+1, r.history[i])
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestREPL_Commands(t *testing.T) {
+	history := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(history, []byte(":src fr\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		":swap",
+		":src german",
+		":dest es",
+		":swap",
+		":dest auto",
+		":bogus",
+		":history 3",
+		":quit",
+	}, "\n")
+	var stdout, stderr bytes.Buffer
+	args := []string{"repl", "-history", history}
+	if code := run(args, strings.NewReader(input), &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		"error: cannot swap an auto source",
+		"[es>de] ",
+		"error: destination language cannot be auto",
+		"error: unknown command :bogus",
+		"    7  :bogus\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	data, err := os.ReadFile(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 9 {
+		t.Fatalf("history file has %d lines, want 9:\n%s", lines, data)
+	}
+}
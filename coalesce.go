@@ -0,0 +1,70 @@
+package translator
+
+import (
+	"context"
+	"sync"
+)
+
+// flightKey identifies identical translation calls.
+type flightKey struct {
+	origin, src, dest string
+}
+
+// flight is a translation shared by every caller waiting on it.
+type flight struct {
+	done    chan struct{}
+	text    string
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// coalescer collapses identical in-flight translations into one upstream call.
+type coalescer struct {
+	mu      sync.Mutex
+	flights map[flightKey]*flight
+}
+
+// do returns the result of fn for key, joining a call already in flight for
+// the same key. fn runs detached from the cancellation of ctx: a caller whose
+// context is done stops waiting, and fn is only cancelled once every caller
+// waiting on it is gone.
+func (c *coalescer) do(ctx context.Context, key flightKey, fn func(ctx context.Context) (string, error)) (string, error) {
+	c.mu.Lock()
+	f := c.flights[key]
+	if f == nil {
+		// The detached context keeps the values of ctx, such as the span of the caller.
+		fctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		c.flights[key] = f
+		go func() {
+			f.text, f.err = fn(fctx)
+			c.mu.Lock()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+			c.mu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.text, f.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody needs the result any more; later callers start a new flight.
+			f.cancel()
+			if c.flights[key] == f {
+				delete(c.flights, key)
+			}
+		}
+		c.mu.Unlock()
+		return "", ctx.Err()
+	}
+}
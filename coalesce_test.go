@@ -0,0 +1,91 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTranslate_Coalescing(t *testing.T) {
+	release := make(chan struct{})
+	var hits atomic.Int32
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		hits.Add(1)
+		<-release
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	}), Config{CoalesceRequests: true})
+
+	// The first caller gives up while the others keep waiting.
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := trans.TranslateContext(ctx, "hello", "en", "es")
+		firstErr <- err
+	}()
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*Translated, 4)
+	errs := make([]error, 4)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = trans.TranslateContext(context.Background(), "hello", "en", "es")
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller: got %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil || results[i].Text != "hola" {
+			t.Fatalf("caller %d: got %v, %v", i, results[i], errs[i])
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("%d upstream requests, want 1", n)
+	}
+
+	// Once the flight is over, the next call goes upstream again.
+	if _, err := trans.Translate("hello", "en", "es"); err != nil || hits.Load() != 2 {
+		t.Fatalf("after the flight: %v, %d requests", err, hits.Load())
+	}
+}
+
+func TestCoalescer_AllCallersGone(t *testing.T) {
+	c := &coalescer{flights: map[flightKey]*flight{}}
+	stopped := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := c.do(ctx, flightKey{"a", "en", "es"}, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		close(stopped)
+		return "", ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("flight not cancelled after its only caller left")
+	}
+}
@@ -0,0 +1,201 @@
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// consentCookies accept Google's cookie consent page, which EU traffic is
+// redirected to before the homepage. SOCS is read by current consent pages and
+// CONSENT by older ones.
+var consentCookies = []*http.Cookie{
+	{Name: "SOCS", Value: "CAESEwgDEgk0ODE3Nzk3MjQaAmVuIAEaBgiA_LyaBg", Path: "/", MaxAge: 13 * 30 * 24 * 3600},
+	{Name: "CONSENT", Value: "YES+cb", Path: "/", MaxAge: 13 * 30 * 24 * 3600},
+}
+
+// fileJarSaveDelay is how long a FileJar waits after a change before saving,
+// so that the cookies of a burst of responses are written once.
+const fileJarSaveDelay = time.Second
+
+// acceptConsent stores the consent cookies in jar for the registrable domain
+// of the host of u, such as google.com for translate.google.com, which the
+// consent pages of its other hosts read too. IP addresses and hosts without a
+// registrable domain get host-only cookies.
+func acceptConsent(jar http.CookieJar, u *url.URL) {
+	domain := ""
+	if host := u.Hostname(); net.ParseIP(host) == nil {
+		domain, _ = publicsuffix.EffectiveTLDPlusOne(host)
+	}
+	cookies := make([]*http.Cookie, len(consentCookies))
+	for i, c := range consentCookies {
+		c := *c
+		c.Domain = domain
+		cookies[i] = &c
+	}
+	jar.SetCookies(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}, cookies)
+}
+
+// FileJar is an http.CookieJar that persists its cookies to a file, so that
+// consent and session cookies survive restarts. Set it as Config.CookieJar and
+// call Close when done with it.
+type FileJar struct {
+	path string
+	jar  *cookiejar.Jar
+
+	mu      sync.Mutex // guards entries, dirty, timer and closed
+	entries map[string]storedCookie
+	dirty   bool        // entries changed since the last save
+	timer   *time.Timer // pending save, nil when none
+	closed  bool        // no more saves are scheduled
+	saveMu  sync.Mutex  // serializes writes of the file
+}
+
+// storedCookie is the file format of a cookie and the URL it was set for.
+type storedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// NewFileJar returns a FileJar backed by the file at path, loading the cookies
+// it already holds. A missing file is created on the first save.
+func NewFileJar(path string) (*FileJar, error) {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	j := &FileJar{path: path, jar: jar, entries: map[string]storedCookie{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored []storedCookie
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	for _, s := range stored {
+		u, err := url.Parse(s.URL)
+		if err != nil || s.Cookie == nil || expired(s.Cookie) {
+			continue
+		}
+		j.jar.SetCookies(u, []*http.Cookie{s.Cookie})
+		j.entries[cookieKey(u, s.Cookie)] = s
+	}
+	return j, nil
+}
+
+// Cookies implements http.CookieJar.
+func (j *FileJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SetCookies implements http.CookieJar. The jar is saved shortly after, once
+// for a burst of changes; errors of that save are dropped. Call Save or Close
+// to check them.
+func (j *FileJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	origin := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}
+	j.mu.Lock()
+	for _, c := range cookies {
+		c := *c
+		// Store an absolute expiry, so that restoring does not extend the lifetime.
+		if c.MaxAge > 0 {
+			c.Expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+			c.MaxAge = 0
+		}
+		key := cookieKey(u, &c)
+		if c.MaxAge < 0 || expired(&c) {
+			delete(j.entries, key)
+			continue
+		}
+		j.entries[key] = storedCookie{URL: origin.String(), Cookie: &c}
+	}
+	j.dirty = true
+	if j.timer == nil && !j.closed {
+		j.timer = time.AfterFunc(fileJarSaveDelay, func() { _ = j.Save() })
+	}
+	j.mu.Unlock()
+}
+
+// Close saves the jar if it changed since the last save. The jar keeps
+// working after Close, but its later changes are saved only by Save.
+func (j *FileJar) Close() error {
+	j.mu.Lock()
+	j.closed = true
+	if j.timer != nil {
+		j.timer.Stop()
+		j.timer = nil
+	}
+	dirty := j.dirty
+	j.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	return j.Save()
+}
+
+// Save writes the unexpired cookies of the jar to its file.
+func (j *FileJar) Save() error {
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	j.mu.Lock()
+	j.dirty = false
+	if j.timer != nil {
+		j.timer.Stop()
+		j.timer = nil
+	}
+	stored := make([]storedCookie, 0, len(j.entries))
+	for key, s := range j.entries {
+		if expired(s.Cookie) {
+			delete(j.entries, key)
+			continue
+		}
+		stored = append(stored, s)
+	}
+	j.mu.Unlock()
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a crash never leaves a truncated jar.
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
+
+// cookieKey identifies a cookie the way the jar does: by domain, path and name.
+func cookieKey(u *url.URL, c *http.Cookie) string {
+	domain := strings.TrimPrefix(c.Domain, ".")
+	if domain == "" {
+		domain = u.Hostname()
+	}
+	return domain + ";" + c.Path + ";" + c.Name
+}
+
+func expired(c *http.Cookie) bool {
+	return !c.Expires.IsZero() && c.Expires.Before(time.Now())
+}
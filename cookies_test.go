@@ -0,0 +1,88 @@
+package translator
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const consentPage = `<html><body><h1>Before you continue to Google</h1></body></html>`
+
+func TestCookieJar_Consent(t *testing.T) {
+	consentShown := 0
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("SOCS"); err != nil {
+			consentShown++
+			w.Write([]byte(consentPage))
+			return
+		}
+		if r.URL.Path != "/translate_a/single" {
+			http.SetCookie(w, &http.Cookie{Name: "NID", Value: "session", Path: "/", MaxAge: 3600})
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	})
+
+	if _, err := newTestTranslator(t, h).Translate("hello", "en", "es"); !errors.Is(err, ErrConsent) {
+		t.Fatalf("without a jar: got %v, want ErrConsent", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := NewFileJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consentShown = 0
+	result, err := newTestTranslator(t, h, Config{CookieJar: jar}).Translate("hello", "en", "es")
+	if err != nil || result.Text != "hola" {
+		t.Fatalf("got %v, %v", result, err)
+	}
+	if consentShown != 1 {
+		t.Fatalf("consent page shown %d times, want 1", consentShown)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("jar saved before Close: %v", err)
+	}
+	if err := jar.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new jar restores the consent and session cookies from the file.
+	jar, err = NewFileJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jar.entries) != 3 {
+		t.Fatalf("restored %d cookies, want 3: %+v", len(jar.entries), jar.entries)
+	}
+	consentShown = 0
+	if _, err := newTestTranslator(t, h, Config{CookieJar: jar}).Translate("hello", "en", "es"); err != nil {
+		t.Fatal(err)
+	}
+	if consentShown != 0 {
+		t.Fatalf("consent page shown %d times after restart", consentShown)
+	}
+}
+
+func TestAcceptConsent_Domain(t *testing.T) {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	acceptConsent(jar, &url.URL{Scheme: "https", Host: "translate.google.co.uk"})
+	if got := jar.Cookies(&url.URL{Scheme: "https", Host: "consent.google.co.uk", Path: "/"}); len(got) != 2 {
+		t.Fatalf("consent host got %v, want the consent cookies", got)
+	}
+	if got := jar.Cookies(&url.URL{Scheme: "https", Host: "example.co.uk", Path: "/"}); len(got) != 0 {
+		t.Fatalf("other domain got %v", got)
+	}
+
+	acceptConsent(jar, &url.URL{Scheme: "http", Host: "127.0.0.1:8080"})
+	if got := jar.Cookies(&url.URL{Scheme: "http", Host: "127.0.0.1:8080", Path: "/"}); len(got) != 2 {
+		t.Fatalf("IP host got %v, want the consent cookies", got)
+	}
+}
@@ -0,0 +1,141 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxErrorBody is the number of response bytes kept in an HTTPError.
+const maxErrorBody = 256
+
+var (
+	// ErrRateLimited is matched by errors caused by Google throttling the client (HTTP 429).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrCaptcha is matched by errors caused by Google answering with a captcha or "unusual traffic" page.
+	ErrCaptcha = errors.New("blocked by captcha")
+	// ErrConsent is matched by errors caused by Google redirecting to its cookie consent page.
+	ErrConsent = errors.New("blocked by consent page")
+	// ErrInvalidLanguage is matched by errors caused by an unknown language code or name.
+	ErrInvalidLanguage = errors.New("invalid language")
+	// ErrUnexpectedResponse is matched by errors caused by a response that is not a valid translation result.
+	ErrUnexpectedResponse = errors.New("unexpected response")
+	// ErrNoProxy is returned when every proxy of the pool is evicted.
+	ErrNoProxy = errors.New("no proxy available")
+	// ErrCircuitOpen is matched by errors of requests refused by an open circuit breaker.
+	ErrCircuitOpen = errors.New("circuit open")
+)
+
+// HTTPError is returned when the service answers with a status code other than 200.
+// It matches ErrUnexpectedResponse with errors.Is, and ErrRateLimited for status 429.
+type HTTPError struct {
+	StatusCode int    // HTTP status code of the response
+	Host       string // host that served the response
+	Body       string // start of the response body, truncated to a few hundred bytes
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("expected statusCode 200, got: %d from %s", e.StatusCode, e.Host)
+	if e.Body != "" {
+		msg += fmt.Sprintf("; body: %q", e.Body)
+	}
+	return msg
+}
+
+// Is reports whether the error matches one of the package sentinels.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrUnexpectedResponse:
+		return true
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// Retryable reports whether repeating the request later may succeed.
+func (e *HTTPError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsRetryable reports whether the operation that returned err may succeed if repeated later.
+// Rate limiting, 5xx responses and network failures are retryable; invalid languages,
+// block pages, malformed responses and cancelled contexts are not.
+//
+// Example Usage:
+//
+//	translated, err := translator.Translate(originText, "auto", "es")
+//	if translator.IsRetryable(err) {
+//	  time.Sleep(time.Second)
+//	  translated, err = translator.Translate(originText, "auto", "es")
+//	}
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// checkResponse returns a *BlockedError when resp is a block page served with
+// an error status, or an *HTTPError for any other status than 200. Only the
+// start of the body is read, so the caller must still close it.
+func checkResponse(host string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBlockScan))
+	if err := checkBlocked(host, resp, body); err != nil {
+		return err
+	}
+	if len(body) > maxErrorBody {
+		body = body[:maxErrorBody]
+	}
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		Host:       host,
+		Body:       sanitizeBody(body),
+	}
+}
+
+// unexpectedResponse wraps a failure to decode the body of a 200 response.
+func unexpectedResponse(host string, err error) error {
+	return fmt.Errorf("%w from %s: %v", ErrUnexpectedResponse, host, err)
+}
+
+// sanitizeBody turns the start of a response body into a short, printable string.
+func sanitizeBody(body []byte) string {
+	return strings.Join(strings.Fields(strings.ToValidUTF8(string(body), "")), " ")
+}
+
+// redactURLError strips the query string from the URL of a *url.Error, as it
+// holds the text being translated and the request token.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	if u, perr := url.Parse(urlErr.URL); perr == nil {
+		u.RawQuery = ""
+		urlErr.URL = u.String()
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package translator
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTranslate_HTTPError(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/translate_a/single" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(strings.Repeat("slow down ", 100)))
+		}
+	}))
+
+	_, err := trans.Translate("secret text", "en", "es")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("error %v is not an *HTTPError", err)
+	}
+	if httpErr.StatusCode != http.StatusTooManyRequests || len(httpErr.Body) > maxErrorBody {
+		t.Fatalf("unexpected error %+v", httpErr)
+	}
+	if !errors.Is(err, ErrRateLimited) || !errors.Is(err, ErrUnexpectedResponse) || errors.Is(err, ErrCaptcha) {
+		t.Fatalf("error %v matches the wrong sentinels", err)
+	}
+	if !IsRetryable(err) {
+		t.Fatalf("rate limiting should be retryable")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Fatalf("error message leaks the text: %v", err)
+	}
+}
+
+func TestTranslate_MalformedResponse(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>not json</html>"))
+	}))
+
+	_, err := trans.Translate("hello", "en", "es")
+	if !errors.Is(err, ErrUnexpectedResponse) || IsRetryable(err) {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestTranslate_NetworkErrorRedacted(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/translate_a/single" {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}
+	}))
+
+	_, err := trans.Translate("secret text", "en", "es")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Fatalf("error message leaks the text: %v", err)
+	}
+	if !IsRetryable(err) {
+		t.Fatalf("network error %v should be retryable", err)
+	}
+}
+
+func TestGetValidLanguageKey_Error(t *testing.T) {
+	_, err := GetValidLanguageKey("klingon")
+	if !errors.Is(err, ErrInvalidLanguage) || err.Error() != "invalid language 'klingon'" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
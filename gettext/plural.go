@@ -0,0 +1,288 @@
+package gettext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pluralForms are the Plural-Forms headers of the languages with other rules
+// than English, by language code. Region variants fall back to the language.
+var pluralForms = map[string]string{
+	// One form.
+	"id": "nplurals=1; plural=0;", "ja": "nplurals=1; plural=0;", "jw": "nplurals=1; plural=0;",
+	"km": "nplurals=1; plural=0;", "ko": "nplurals=1; plural=0;", "lo": "nplurals=1; plural=0;",
+	"ms": "nplurals=1; plural=0;", "my": "nplurals=1; plural=0;", "su": "nplurals=1; plural=0;",
+	"th": "nplurals=1; plural=0;", "vi": "nplurals=1; plural=0;", "zh": "nplurals=1; plural=0;",
+
+	// Singular for 0 and 1.
+	"fr": "nplurals=2; plural=(n > 1);", "fil": "nplurals=2; plural=(n > 1);",
+	"tl": "nplurals=2; plural=(n > 1);", "hy": "nplurals=2; plural=(n > 1);",
+	"ln": "nplurals=2; plural=(n > 1);", "oc": "nplurals=2; plural=(n > 1);",
+
+	"is": "nplurals=2; plural=(n%10!=1 || n%100==11);",
+	"mk": "nplurals=2; plural=(n==1 || n%10==1 ? 0 : 1);",
+
+	"be": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"bs": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"hr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"ru": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"sr": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"uk": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"cs": "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+	"sk": "nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);",
+	"pl": "nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"lt": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n%10>=2 && (n%100<10 || n%100>=20) ? 1 : 2);",
+	"lv": "nplurals=3; plural=(n%10==1 && n%100!=11 ? 0 : n != 0 ? 1 : 2);",
+	"ro": "nplurals=3; plural=(n==1 ? 0 : (n==0 || (n%100 > 0 && n%100 < 20)) ? 1 : 2);",
+	"sl": "nplurals=4; plural=(n%100==1 ? 0 : n%100==2 ? 1 : n%100==3 || n%100==4 ? 2 : 3);",
+	"cy": "nplurals=4; plural=(n==1 ? 0 : n==2 ? 1 : n != 8 && n != 11 ? 2 : 3);",
+	"gd": "nplurals=4; plural=(n==1 || n==11 ? 0 : n==2 || n==12 ? 1 : n > 2 && n < 20 ? 2 : 3);",
+	"mt": "nplurals=4; plural=(n==1 ? 0 : n==0 || (n%100>1 && n%100<11) ? 1 : n%100>10 && n%100<20 ? 2 : 3);",
+	"ga": "nplurals=5; plural=(n==1 ? 0 : n==2 ? 1 : n<7 ? 2 : n<11 ? 3 : 4);",
+	"ar": "nplurals=6; plural=(n==0 ? 0 : n==1 ? 1 : n==2 ? 2 : n%100>=3 && n%100<=10 ? 3 : n%100>=11 ? 4 : 5);",
+}
+
+// defaultPluralForms is the rule of English and most European languages.
+const defaultPluralForms = "nplurals=2; plural=(n != 1);"
+
+// PluralFormsFor returns the Plural-Forms header value for a language code
+// such as "ru" or "zh-cn".
+func PluralFormsFor(lang string) string {
+	lang = strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	if forms, ok := pluralForms[lang]; ok {
+		return forms
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	if forms, ok := pluralForms[base]; ok {
+		return forms
+	}
+	return defaultPluralForms
+}
+
+// Plural is a parsed Plural-Forms rule.
+type Plural struct {
+	N    int // number of forms
+	expr func(n int) int
+}
+
+// ParsePlural parses a Plural-Forms header value such as
+// "nplurals=2; plural=(n != 1);".
+func ParsePlural(forms string) (*Plural, error) {
+	p := &Plural{}
+	var expr string
+	for _, field := range strings.Split(forms, ";") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "nplurals":
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("gettext: invalid nplurals in %q", forms)
+			}
+			p.N = n
+		case "plural":
+			expr = value
+		}
+	}
+	if p.N == 0 || expr == "" {
+		return nil, fmt.Errorf("gettext: invalid plural forms %q", forms)
+	}
+	ps := &pluralParser{input: expr}
+	e, err := ps.ternary()
+	if err == nil && strings.TrimSpace(ps.input[ps.pos:]) != "" {
+		err = fmt.Errorf("unexpected %q", ps.input[ps.pos:])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gettext: invalid plural expression %q: %v", expr, err)
+	}
+	p.expr = e
+	return p, nil
+}
+
+// Form returns the index of the form used for n.
+func (p *Plural) Form(n int) int {
+	i := p.expr(n)
+	if i < 0 || i >= p.N {
+		return 0
+	}
+	return i
+}
+
+// Samples returns, for each form, the smallest number using it, or -1 for a
+// form that no number up to 1000 uses.
+func (p *Plural) Samples() []int {
+	samples := make([]int, p.N)
+	for i := range samples {
+		samples[i] = -1
+	}
+	for n, left := 0, p.N; n <= 1000 && left > 0; n++ {
+		if i := p.Form(n); samples[i] < 0 {
+			samples[i] = n
+			left--
+		}
+	}
+	return samples
+}
+
+// pluralParser parses the C subset used by plural expressions by recursive
+// descent, one precedence level per method.
+type pluralParser struct {
+	input string
+	pos   int
+}
+
+type pluralExpr = func(n int) int
+
+func (p *pluralParser) peek(tokens ...string) string {
+	rest := strings.TrimLeft(p.input[p.pos:], " \t\n")
+	for _, tok := range tokens {
+		if strings.HasPrefix(rest, tok) {
+			// "<" must not match the start of "<=", nor "!" of "!=".
+			if (tok == "<" || tok == ">" || tok == "!") && strings.HasPrefix(rest[1:], "=") {
+				continue
+			}
+			return tok
+		}
+	}
+	return ""
+}
+
+func (p *pluralParser) next(tok string) {
+	p.pos = len(p.input) - len(strings.TrimLeft(p.input[p.pos:], " \t\n")) + len(tok)
+}
+
+func (p *pluralParser) ternary() (pluralExpr, error) {
+	cond, err := p.binary(0)
+	if err != nil || p.peek("?") == "" {
+		return cond, err
+	}
+	p.next("?")
+	yes, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek(":") == "" {
+		return nil, fmt.Errorf("missing ':'")
+	}
+	p.next(":")
+	no, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(n int) int {
+		if cond(n) != 0 {
+			return yes(n)
+		}
+		return no(n)
+	}, nil
+}
+
+// pluralLevels are the binary operators from the lowest precedence up.
+var pluralLevels = [][]string{
+	{"||"}, {"&&"}, {"==", "!="}, {"<=", ">=", "<", ">"}, {"+", "-"}, {"*", "/", "%"},
+}
+
+func (p *pluralParser) binary(level int) (pluralExpr, error) {
+	if level == len(pluralLevels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	for err == nil {
+		op := p.peek(pluralLevels[level]...)
+		if op == "" {
+			break
+		}
+		p.next(op)
+		var right pluralExpr
+		if right, err = p.binary(level + 1); err == nil {
+			left = pluralOp(op, left, right)
+		}
+	}
+	return left, err
+}
+
+func pluralOp(op string, l, r pluralExpr) pluralExpr {
+	b := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	switch op {
+	case "||":
+		return func(n int) int { return b(l(n) != 0 || r(n) != 0) }
+	case "&&":
+		return func(n int) int { return b(l(n) != 0 && r(n) != 0) }
+	case "==":
+		return func(n int) int { return b(l(n) == r(n)) }
+	case "!=":
+		return func(n int) int { return b(l(n) != r(n)) }
+	case "<=":
+		return func(n int) int { return b(l(n) <= r(n)) }
+	case ">=":
+		return func(n int) int { return b(l(n) >= r(n)) }
+	case "<":
+		return func(n int) int { return b(l(n) < r(n)) }
+	case ">":
+		return func(n int) int { return b(l(n) > r(n)) }
+	case "+":
+		return func(n int) int { return l(n) + r(n) }
+	case "-":
+		return func(n int) int { return l(n) - r(n) }
+	case "*":
+		return func(n int) int { return l(n) * r(n) }
+	}
+	// Division by zero yields 0 rather than a panic.
+	return func(n int) int {
+		d := r(n)
+		if d == 0 {
+			return 0
+		}
+		if op == "/" {
+			return l(n) / d
+		}
+		return l(n) % d
+	}
+}
+
+func (p *pluralParser) unary() (pluralExpr, error) {
+	switch p.peek("!", "(", "n") {
+	case "!":
+		p.next("!")
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(n int) int {
+			if e(n) == 0 {
+				return 1
+			}
+			return 0
+		}, nil
+	case "(":
+		p.next("(")
+		e, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek(")") == "" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.next(")")
+		return e, nil
+	case "n":
+		p.next("n")
+		return func(n int) int { return n }, nil
+	}
+	rest := strings.TrimLeft(p.input[p.pos:], " \t\n")
+	digits := len(rest) - len(strings.TrimLeft(rest, "0123456789"))
+	if digits == 0 {
+		return nil, fmt.Errorf("unexpected %q", rest)
+	}
+	v, _ := strconv.Atoi(rest[:digits])
+	p.next(rest[:digits])
+	return func(int) int { return v }, nil
+}
@@ -0,0 +1,53 @@
+package gettext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePlural(t *testing.T) {
+	tests := []struct {
+		lang    string
+		forms   []int // form of 0, 1, 2, 5, 11, 21, 22, 101
+		samples []int
+	}{
+		{"en", []int{1, 0, 1, 1, 1, 1, 1, 1}, []int{1, 0}},
+		{"fr", []int{0, 0, 1, 1, 1, 1, 1, 1}, []int{0, 2}},
+		{"ja", []int{0, 0, 0, 0, 0, 0, 0, 0}, []int{0}},
+		{"ru", []int{2, 0, 1, 2, 2, 0, 1, 0}, []int{1, 2, 0}},
+		{"pl", []int{2, 0, 1, 2, 2, 2, 1, 2}, []int{1, 2, 0}},
+		{"ar", []int{0, 1, 2, 3, 4, 4, 4, 5}, []int{0, 1, 2, 3, 11, 100}},
+		{"zh-TW", []int{0, 0, 0, 0, 0, 0, 0, 0}, []int{0}},
+		{"pt-br", []int{1, 0, 1, 1, 1, 1, 1, 1}, []int{1, 0}},
+	}
+	for _, tt := range tests {
+		p, err := ParsePlural(PluralFormsFor(tt.lang))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.lang, err)
+		}
+		var forms []int
+		for _, n := range []int{0, 1, 2, 5, 11, 21, 22, 101} {
+			forms = append(forms, p.Form(n))
+		}
+		if !reflect.DeepEqual(forms, tt.forms) {
+			t.Errorf("%s: got forms %v, want %v", tt.lang, forms, tt.forms)
+		}
+		if got := p.Samples(); !reflect.DeepEqual(got, tt.samples) {
+			t.Errorf("%s: got samples %v, want %v", tt.lang, got, tt.samples)
+		}
+	}
+}
+
+func TestParsePlural_Errors(t *testing.T) {
+	for _, forms := range []string{
+		"",
+		"nplurals=INTEGER; plural=EXPRESSION;",
+		"nplurals=2; plural=(n != 1;",
+		"nplurals=2; plural=n ? 1;",
+		"nplurals=2; plural=n !! 1;",
+	} {
+		if _, err := ParsePlural(forms); err == nil {
+			t.Errorf("ParsePlural(%q): no error", forms)
+		}
+	}
+}
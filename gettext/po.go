@@ -0,0 +1,350 @@
+// Package gettext reads, machine-translates and writes gettext PO and POT files.
+//
+//	f, err := gettext.Parse(in)
+//	if err != nil {
+//	  return err
+//	}
+//	if _, err := gettext.Translate(ctx, t, f, "en", "fr"); err != nil {
+//	  return err
+//	}
+//	_, err = f.WriteTo(out)
+package gettext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Entry is a message of a PO file with its comments.
+type Entry struct {
+	TranslatorComments []string // "# " lines
+	ExtractedComments  []string // "#." lines
+	References         []string // "#:" lines
+	Flags              []string // from "#," lines, e.g. "fuzzy" or "c-format"
+	Previous           []string // "#|" lines, kept as written
+
+	Context   string   // msgctxt, none when empty
+	ID        string   // msgid
+	IDPlural  string   // msgid_plural, none when empty
+	Str       string   // msgstr of a message without plural
+	StrPlural []string // msgstr[n] of a message with plural
+	Obsolete  bool     // the entry is commented out with "#~"
+}
+
+// File is a parsed PO or POT file. The header is the entry with an empty ID.
+type File struct {
+	Entries []*Entry
+}
+
+// Translated reports whether the entry has a translation.
+func (e *Entry) Translated() bool {
+	if e.IDPlural == "" {
+		return e.Str != ""
+	}
+	for _, s := range e.StrPlural {
+		if s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFlag reports whether the entry has flag.
+func (e *Entry) HasFlag(flag string) bool {
+	for _, f := range e.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Header returns the header entry, nil if the file has none.
+func (f *File) Header() *Entry {
+	for _, e := range f.Entries {
+		if e.ID == "" && e.Context == "" && !e.Obsolete {
+			return e
+		}
+	}
+	return nil
+}
+
+// HeaderField returns the value of a field of the header, such as "Language"
+// or "Plural-Forms".
+func (f *File) HeaderField(name string) string {
+	h := f.Header()
+	if h == nil {
+		return ""
+	}
+	for _, line := range strings.Split(h.Str, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// minimalHeader starts the header added by SetHeaderField, declaring the
+// UTF-8 encoding of the messages.
+const minimalHeader = "MIME-Version: 1.0\nContent-Type: text/plain; charset=UTF-8\nContent-Transfer-Encoding: 8bit\n"
+
+// SetHeaderField sets a field of the header. A missing header is added with
+// the MIME fields declaring UTF-8, which gettext tools need to read the
+// messages.
+func (f *File) SetHeaderField(name, value string) {
+	h := f.Header()
+	if h == nil {
+		h = &Entry{Str: minimalHeader}
+		f.Entries = append([]*Entry{h}, f.Entries...)
+	}
+	lines := strings.Split(strings.TrimSuffix(h.Str, "\n"), "\n")
+	if h.Str == "" {
+		lines = nil
+	}
+	for i, line := range lines {
+		if key, _, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			lines[i] = name + ": " + value
+			h.Str = strings.Join(lines, "\n") + "\n"
+			return
+		}
+	}
+	h.Str = strings.Join(append(lines, name+": "+value), "\n") + "\n"
+}
+
+// Parse reads a PO or POT file.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var e *Entry
+	var target *string // string continued by the following quoted lines
+	lineNo := 0
+	flush := func() {
+		if e != nil {
+			f.Entries = append(f.Entries, e)
+			e, target = nil, nil
+		}
+	}
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" {
+			flush()
+			continue
+		}
+
+		obsolete := false
+		if strings.HasPrefix(line, "#~") {
+			obsolete = true
+			line = strings.TrimSpace(line[2:])
+			if strings.HasPrefix(line, "|") {
+				// "#~|" holds the previous msgid of an obsolete entry.
+				line = "#" + line
+			}
+		}
+		if strings.HasPrefix(line, "#") {
+			// A comment after the strings of an entry starts the next one.
+			if target != nil {
+				flush()
+			}
+			if e == nil {
+				e = &Entry{}
+			}
+			kind, text := commentKind(line)
+			switch kind {
+			case ".":
+				e.ExtractedComments = append(e.ExtractedComments, text)
+			case ":":
+				e.References = append(e.References, text)
+			case ",":
+				for _, flag := range strings.Split(text, ",") {
+					if flag = strings.TrimSpace(flag); flag != "" {
+						e.Flags = append(e.Flags, flag)
+					}
+				}
+			case "|":
+				e.Previous = append(e.Previous, text)
+			default:
+				e.TranslatorComments = append(e.TranslatorComments, text)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, `"`) {
+			if target == nil {
+				return nil, fmt.Errorf("gettext: line %d: unexpected string", lineNo)
+			}
+			s, err := unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("gettext: line %d: %v", lineNo, err)
+			}
+			*target += s
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(line, " ")
+		s, err := unquote(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("gettext: line %d: %v", lineNo, err)
+		}
+		// A new message may follow without a blank line in between.
+		if target != nil && (keyword == "msgctxt" || keyword == "msgid" && target != &e.Context) {
+			flush()
+		}
+		if e == nil {
+			e = &Entry{}
+		}
+		e.Obsolete = e.Obsolete || obsolete
+		switch {
+		case keyword == "msgctxt":
+			e.Context, target = s, &e.Context
+		case keyword == "msgid":
+			e.ID, target = s, &e.ID
+		case keyword == "msgid_plural":
+			e.IDPlural, target = s, &e.IDPlural
+		case keyword == "msgstr":
+			e.Str, target = s, &e.Str
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || n != len(e.StrPlural) {
+				return nil, fmt.Errorf("gettext: line %d: unexpected %s", lineNo, keyword)
+			}
+			e.StrPlural = append(e.StrPlural, s)
+			target = &e.StrPlural[n]
+		default:
+			return nil, fmt.Errorf("gettext: line %d: unknown keyword %q", lineNo, keyword)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return f, nil
+}
+
+// commentKind splits a comment line into its kind, the character after "#",
+// and its text.
+func commentKind(line string) (string, string) {
+	if len(line) > 1 && strings.ContainsRune(".:,|", rune(line[1])) {
+		return line[1:2], strings.TrimSpace(line[2:])
+	}
+	return "", strings.TrimPrefix(strings.TrimPrefix(line, "#"), " ")
+}
+
+// unquote decodes a C string literal.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s)-1 {
+			return "", fmt.Errorf("invalid string %s", s)
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'a':
+			b.WriteByte('\a')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// quote encodes s as a C string literal.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// WriteTo writes the file in PO format.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for i, e := range f.Entries {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		e.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (e *Entry) write(b *strings.Builder) {
+	for _, c := range e.TranslatorComments {
+		b.WriteString(strings.TrimRight("# "+c, " ") + "\n")
+	}
+	for _, c := range e.ExtractedComments {
+		b.WriteString("#. " + c + "\n")
+	}
+	for _, c := range e.References {
+		b.WriteString("#: " + c + "\n")
+	}
+	if len(e.Flags) > 0 {
+		b.WriteString("#, " + strings.Join(e.Flags, ", ") + "\n")
+	}
+	for _, c := range e.Previous {
+		b.WriteString("#| " + c + "\n")
+	}
+
+	prefix := ""
+	if e.Obsolete {
+		prefix = "#~ "
+	}
+	if e.Context != "" {
+		writeString(b, prefix, "msgctxt", e.Context)
+	}
+	writeString(b, prefix, "msgid", e.ID)
+	if e.IDPlural == "" {
+		writeString(b, prefix, "msgstr", e.Str)
+		return
+	}
+	writeString(b, prefix, "msgid_plural", e.IDPlural)
+	for i, s := range e.StrPlural {
+		writeString(b, prefix, "msgstr["+strconv.Itoa(i)+"]", s)
+	}
+	if len(e.StrPlural) == 0 {
+		writeString(b, prefix, "msgstr[0]", "")
+	}
+}
+
+// writeString writes a keyword and its string, over several lines when the
+// string holds line breaks other than a final one.
+func writeString(b *strings.Builder, prefix, keyword, s string) {
+	if !strings.Contains(strings.TrimSuffix(s, "\n"), "\n") {
+		b.WriteString(prefix + keyword + " " + quote(s) + "\n")
+		return
+	}
+	b.WriteString(prefix + keyword + ` ""` + "\n")
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line != "" {
+			b.WriteString(prefix + quote(line) + "\n")
+		}
+	}
+}
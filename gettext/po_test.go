@@ -0,0 +1,107 @@
+package gettext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const poFile = `# French translation.
+msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: fr\n"
+"Plural-Forms: nplurals=2; plural=(n > 1);\n"
+
+#. Shown on the home page.
+#: src/home.c:12 src/home.c:40
+#, c-format
+msgid "Hello %s"
+msgstr ""
+
+#, fuzzy
+#| msgid "Goodbye"
+msgctxt "menu"
+msgid "Quit"
+msgstr "Quitter"
+
+#: src/files.c:7
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid ""
+"A \"quoted\" line\n"
+"and another\n"
+msgstr ""
+
+#~ msgid "Old"
+#~ msgstr "Vieux"
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(poFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Entries) != 6 {
+		t.Fatalf("got %d entries, want 6", len(f.Entries))
+	}
+	if got := f.HeaderField("plural-forms"); got != "nplurals=2; plural=(n > 1);" {
+		t.Fatalf("got Plural-Forms %q", got)
+	}
+
+	want := &Entry{
+		ExtractedComments: []string{"Shown on the home page."},
+		References:        []string{"src/home.c:12 src/home.c:40"},
+		Flags:             []string{"c-format"},
+		ID:                "Hello %s",
+	}
+	if !reflect.DeepEqual(f.Entries[1], want) {
+		t.Fatalf("got %+v, want %+v", f.Entries[1], want)
+	}
+	if e := f.Entries[2]; e.Context != "menu" || !e.HasFlag("fuzzy") || e.Previous[0] != `msgid "Goodbye"` || !e.Translated() {
+		t.Fatalf("got %+v", e)
+	}
+	if e := f.Entries[3]; e.IDPlural != "%d files" || len(e.StrPlural) != 2 || e.Translated() {
+		t.Fatalf("got %+v", e)
+	}
+	if e := f.Entries[4]; e.ID != "A \"quoted\" line\nand another\n" {
+		t.Fatalf("got %q", e.ID)
+	}
+	if e := f.Entries[5]; !e.Obsolete || e.Str != "Vieux" {
+		t.Fatalf("got %+v", e)
+	}
+
+	var b strings.Builder
+	if _, err := f.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != poFile {
+		t.Fatalf("round trip:\n%s", b.String())
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, in := range []string{
+		"\"stray\"\n",
+		"msgid \"a\"\nmsgstr[1] \"b\"\n",
+		"msgid \"a\nmsgstr \"\"\n",
+		"msgfoo \"a\"\n",
+	} {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("Parse(%q): no error", in)
+		}
+	}
+}
+
+func TestSetHeaderField(t *testing.T) {
+	f := &File{Entries: []*Entry{{ID: "Hi"}}}
+	f.SetHeaderField("Language", "de")
+	f.SetHeaderField("Plural-Forms", "nplurals=2; plural=(n != 1);")
+	f.SetHeaderField("Language", "de_AT")
+	if got, want := f.Entries[0].Str, minimalHeader+"Language: de_AT\nPlural-Forms: nplurals=2; plural=(n != 1);\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
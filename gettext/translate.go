@@ -0,0 +1,185 @@
+package gettext
+
+import (
+	"context"
+	"mime"
+	"regexp"
+	"strconv"
+	"strings"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+// placeholders matches the format verbs, placeholders and tags of messages,
+// which are kept as they are: printf verbs such as "%s", "%1$d" and "%(name)s",
+// braces such as "{0}" and "{name}", and HTML tags.
+var placeholders = regexp.MustCompile(`%(?:\d+\$|\([\w.-]+\))?[-+#0']*(?:\d+|\*)?(?:\.(?:\d+|\*))?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcsp%]|\{[\w.:\[\]-]*\}|<[^<>]+>`)
+
+// countVerb matches the placeholder of the number a plural message is chosen by.
+var countVerb = regexp.MustCompile(`%(?:\d+\$)?(?:ll|l|h|z|j)?[diu]|%\(\w+\)[diu]|\{(?:0|n|num|count|number)\}`)
+
+// Options controls how Translate marks the entries it fills in.
+type Options struct {
+	// Comment is the translator comment added to the entries translated.
+	// Defaults to "Machine translated from <src> to <dest>".
+	Comment string
+	// NoFuzzy leaves the fuzzy flag off the entries translated.
+	NoFuzzy bool
+}
+
+// form is the text translated for one msgstr of an entry.
+type form struct {
+	entry  *Entry
+	index  int    // msgstr[index], -1 for msgstr
+	source string // the source text
+	text   string // the text sent, with the count placeholder replaced by sample
+	verb   string // the count placeholder replaced, if any
+	sample string
+	result string // the translation
+}
+
+// Translate translates the untranslated entries of f from src to dest in
+// place and returns how many were translated. Obsolete entries and entries
+// with a translation, fuzzy or not, are left alone.
+//
+// Messages with a plural get one translation per plural form of dest, taken
+// from the Plural-Forms header, which is set from dest when missing. Each form
+// is translated with the count placeholder replaced by a number using it, so
+// that the words around it agree with the number. Format verbs, placeholders
+// and tags are kept. The translated entries are marked fuzzy and get a
+// translator comment, so that they are reviewed before use. f is left as it
+// was when the translation fails.
+func Translate(ctx context.Context, t translator.ProtectedTranslator, f *File, src, dest string, opts ...Options) (int, error) {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Comment == "" {
+		o.Comment = "Machine translated from " + src + " to " + dest
+	}
+
+	pluralForms := ""
+	plural, err := ParsePlural(f.HeaderField("Plural-Forms"))
+	if err != nil {
+		pluralForms = PluralFormsFor(dest)
+		if plural, err = ParsePlural(pluralForms); err != nil {
+			return 0, err
+		}
+	}
+	samples := plural.Samples()
+
+	var forms []*form
+	var entries []*Entry
+	for _, e := range f.Entries {
+		if e.Obsolete || e.ID == "" || e.Translated() {
+			continue
+		}
+		entries = append(entries, e)
+		if e.IDPlural == "" {
+			forms = append(forms, &form{entry: e, index: -1, source: e.ID, text: e.ID})
+			continue
+		}
+		for i, n := range samples {
+			fm := &form{entry: e, index: i, source: e.IDPlural}
+			if n == 1 {
+				fm.source = e.ID
+			}
+			fm.text = fm.source
+			if verbs := countVerb.FindAllString(fm.text, -1); n >= 0 && len(verbs) == 1 {
+				fm.verb, fm.sample = verbs[0], strconv.Itoa(n)
+				fm.text = strings.Replace(fm.text, fm.verb, fm.sample, 1)
+			}
+			forms = append(forms, fm)
+		}
+	}
+	if len(forms) == 0 {
+		f.setHeaders(pluralForms, dest)
+		return 0, nil
+	}
+
+	texts := make([]string, len(forms))
+	for i, fm := range forms {
+		texts[i] = fm.text
+	}
+	translated, err := t.TranslateProtected(ctx, texts, placeholders, src, dest)
+	if err != nil {
+		return 0, err
+	}
+
+	// Forms whose sample number cannot be told apart in the translation are
+	// translated again with the placeholder in place.
+	var retry []*form
+	for i, fm := range forms {
+		if fm.verb == "" {
+			fm.result = translated[i]
+			continue
+		}
+		number := regexp.MustCompile(`(^|\D)` + fm.sample + `(\D|$)`)
+		if matches := number.FindAllStringSubmatchIndex(translated[i], -1); len(matches) == 1 {
+			m := matches[0]
+			fm.result = translated[i][:m[3]] + fm.verb + translated[i][m[4]:]
+			continue
+		}
+		retry = append(retry, fm)
+	}
+	if len(retry) > 0 {
+		texts = make([]string, len(retry))
+		for i, fm := range retry {
+			texts[i] = fm.source
+		}
+		if translated, err = t.TranslateProtected(ctx, texts, placeholders, src, dest); err != nil {
+			return 0, err
+		}
+		for i, fm := range retry {
+			fm.result = translated[i]
+		}
+	}
+
+	f.setHeaders(pluralForms, dest)
+	for _, e := range entries {
+		if e.IDPlural != "" {
+			e.StrPlural = make([]string, plural.N)
+		}
+	}
+	for _, fm := range forms {
+		if fm.index < 0 {
+			fm.entry.Str = fm.result
+		} else {
+			fm.entry.StrPlural[fm.index] = fm.result
+		}
+	}
+	for _, e := range entries {
+		if !o.NoFuzzy && !e.HasFlag("fuzzy") {
+			e.Flags = append([]string{"fuzzy"}, e.Flags...)
+		}
+		e.TranslatorComments = append(e.TranslatorComments, o.Comment)
+	}
+	return len(entries), nil
+}
+
+// setHeaders sets the Plural-Forms header to pluralForms, unless empty, the
+// Language header from dest when missing and the charset to UTF-8, the
+// encoding of the translations, when missing or left as the CHARSET
+// placeholder of a POT file.
+func (f *File) setHeaders(pluralForms, dest string) {
+	if pluralForms != "" {
+		f.SetHeaderField("Plural-Forms", pluralForms)
+	}
+	if f.HeaderField("Language") == "" {
+		f.SetHeaderField("Language", languageTag(dest))
+	}
+	_, params, err := mime.ParseMediaType(f.HeaderField("Content-Type"))
+	if charset := params["charset"]; err != nil || charset == "" || strings.EqualFold(charset, "CHARSET") {
+		f.SetHeaderField("Content-Type", "text/plain; charset=UTF-8")
+	}
+}
+
+// languageTag turns a language code such as "zh-cn" into the form used by
+// gettext, "zh_CN".
+func languageTag(lang string) string {
+	base, region, ok := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(base)
+	}
+	return strings.ToLower(base) + "_" + strings.ToUpper(region)
+}
@@ -0,0 +1,180 @@
+package gettext
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lcapuano-app/go-googletrans/internal/translatortest"
+)
+
+// dropDigits drops the digits of the translations of texts about apples, as a
+// translation spelling out a number would.
+func dropDigits(text, translated string) string {
+	if !strings.Contains(text, "apples") {
+		return translated
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return -1
+		}
+		return r
+	}, translated)
+}
+
+// failingTranslator fails every call.
+type failingTranslator struct{}
+
+func (failingTranslator) TranslateProtected(context.Context, []string, *regexp.Regexp, string, string) ([]string, error) {
+	return nil, errors.New("service unavailable")
+}
+
+const potFile = `# SOME DESCRIPTIVE TITLE.
+#, fuzzy
+msgid ""
+msgstr ""
+"Project-Id-Version: demo\n"
+"Language: \n"
+"MIME-Version: 1.0\n"
+"Content-Type: text/plain; charset=CHARSET\n"
+"Content-Transfer-Encoding: 8bit\n"
+"Plural-Forms: nplurals=INTEGER; plural=EXPRESSION;\n"
+
+#: main.c:3
+#, c-format
+msgid "Hello %s, you have <b>%(count)d</b> new"
+msgstr ""
+
+msgid "Done"
+msgstr "Fait"
+
+#: main.c:9
+#, c-format
+msgid "One file"
+msgid_plural "%d files"
+msgstr[0] ""
+msgstr[1] ""
+
+msgid "%d apple"
+msgid_plural "%d apples"
+msgstr[0] ""
+msgstr[1] ""
+
+#~ msgid "Old"
+#~ msgstr ""
+`
+
+func TestTranslate(t *testing.T) {
+	f, err := Parse(strings.NewReader(potFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &translatortest.Fake{Edit: dropDigits}
+	n, err := Translate(context.Background(), fake, f, "en", "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("translated %d entries, want 3", n)
+	}
+	if got, want := f.HeaderField("Language"), "ru"; got != want {
+		t.Fatalf("got Language %q, want %q", got, want)
+	}
+	if got, want := f.HeaderField("Plural-Forms"), PluralFormsFor("ru"); got != want {
+		t.Fatalf("got Plural-Forms %q, want %q", got, want)
+	}
+	// The CHARSET placeholder of the POT file would make msgfmt reject the
+	// non-ASCII translations.
+	if got, want := f.HeaderField("Content-Type"), "text/plain; charset=UTF-8"; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+
+	e := f.Entries[1]
+	if e.Str != "HELLO %s, YOU HAVE <b>%(count)d</b> NEW" {
+		t.Fatalf("got %q", e.Str)
+	}
+	if !e.HasFlag("fuzzy") || !e.HasFlag("c-format") || e.References[0] != "main.c:3" {
+		t.Fatalf("got %+v", e)
+	}
+	if e.TranslatorComments[0] != "Machine translated from en to ru" {
+		t.Fatalf("got comments %q", e.TranslatorComments)
+	}
+	if e := f.Entries[2]; e.Str != "Fait" || e.HasFlag("fuzzy") {
+		t.Fatalf("translated entry changed: %+v", e)
+	}
+
+	// Russian forms are sampled with 1, 2 and 0; the singular has no count.
+	want := []string{"ONE FILE", "%d FILES", "%d FILES"}
+	if got := f.Entries[3].StrPlural; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if got := fake.Calls[0]; !contains(got, "2 files") || !contains(got, "0 files") {
+		t.Fatalf("got texts %q", got)
+	}
+
+	// The numbers lost from "2 apples" and "0 apples" are retried as is.
+	want = []string{"%d APPLE", "%d APPLES", "%d APPLES"}
+	if got := f.Entries[4].StrPlural; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if len(fake.Calls) != 2 || len(fake.Calls[1]) != 2 || fake.Calls[1][0] != "%d apples" {
+		t.Fatalf("got calls %q", fake.Calls)
+	}
+	if e := f.Entries[5]; e.Str != "" {
+		t.Fatalf("obsolete entry translated: %+v", e)
+	}
+
+	var b strings.Builder
+	if _, err := f.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(strings.NewReader(b.String())); err != nil {
+		t.Fatalf("output does not parse: %v\n%s", err, b.String())
+	}
+	if !strings.Contains(b.String(), "# Machine translated from en to ru\n#: main.c:9\n#, fuzzy, c-format\nmsgid \"One file\"") {
+		t.Fatalf("got:\n%s", b.String())
+	}
+}
+
+func TestTranslate_NoFuzzy(t *testing.T) {
+	f, err := Parse(strings.NewReader("msgid \"Hi\"\nmsgstr \"\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Translate(context.Background(), &translatortest.Fake{}, f, "en", "ja", Options{Comment: "MT", NoFuzzy: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.HeaderField("Plural-Forms"), "nplurals=1; plural=0;"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if e := f.Entries[1]; e.Str != "HI" || e.HasFlag("fuzzy") || e.TranslatorComments[0] != "MT" {
+		t.Fatalf("got %+v", e)
+	}
+}
+
+func TestTranslate_Error(t *testing.T) {
+	f, err := Parse(strings.NewReader(potFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var before, after strings.Builder
+	f.WriteTo(&before)
+	if _, err := Translate(context.Background(), failingTranslator{}, f, "en", "ru"); err == nil {
+		t.Fatal("expected an error")
+	}
+	f.WriteTo(&after)
+	if after.String() != before.String() {
+		t.Fatalf("file changed by a failed translation:\n%s", after.String())
+	}
+}
+
+func contains(texts []string, s string) bool {
+	for _, text := range texts {
+		if text == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package translator
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+)
+
+// HeaderProfile is a consistent set of browser headers sent with a request.
+// Empty fields are not sent.
+type HeaderProfile struct {
+	UserAgent      string
+	AcceptLanguage string
+	Referer        string
+}
+
+// apply sets the headers of the profile on h, replacing any previous values.
+func (p HeaderProfile) apply(h http.Header) {
+	if p.UserAgent != "" {
+		h.Set("User-Agent", p.UserAgent)
+	}
+	if p.AcceptLanguage != "" {
+		h.Set("Accept-Language", p.AcceptLanguage)
+	}
+	if p.Referer != "" {
+		h.Set("Referer", p.Referer)
+	}
+}
+
+// HeaderRotation selects how often the header profile of requests changes.
+type HeaderRotation int
+
+const (
+	// RotateHeadersPerClient uses one randomly chosen profile for the lifetime of the Translator.
+	RotateHeadersPerClient HeaderRotation = iota
+	// RotateHeadersPerRequest chooses a random profile for every request.
+	RotateHeadersPerRequest
+	// RotateHeadersPerHost chooses a random profile the first time a service host is used
+	// and keeps it for that host.
+	RotateHeadersPerHost
+)
+
+// BrowserProfiles is a bundled set of header profiles of current desktop and
+// mobile browsers, for use as Config.HeaderProfiles.
+var BrowserProfiles = []HeaderProfile{
+	{
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36 Edg/129.0.0.0",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:131.0) Gecko/20100101 Firefox/131.0",
+		AcceptLanguage: "en-US,en;q=0.5",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+		AcceptLanguage: "en-GB,en;q=0.9",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Safari/605.1.15",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.7; rv:131.0) Gecko/20100101 Firefox/131.0",
+		AcceptLanguage: "en-US,en;q=0.5",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0",
+		AcceptLanguage: "en-US,en;q=0.5",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (iPhone; CPU iPhone OS 18_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Mobile/15E148 Safari/604.1",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+	{
+		UserAgent:      "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Mobile Safari/537.36",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+}
+
+// headerRotator chooses the header profile of each request.
+type headerRotator struct {
+	mu       sync.Mutex // guards byHost
+	profiles []HeaderProfile
+	rotation HeaderRotation
+	fixed    HeaderProfile // the profile of RotateHeadersPerClient
+	byHost   map[string]HeaderProfile
+}
+
+func newHeaderRotator(profiles []HeaderProfile, rotation HeaderRotation) *headerRotator {
+	return &headerRotator{
+		profiles: profiles,
+		rotation: rotation,
+		fixed:    profiles[rand.Intn(len(profiles))],
+		byHost:   map[string]HeaderProfile{},
+	}
+}
+
+// profile returns the profile of a request to host.
+func (r *headerRotator) profile(host string) HeaderProfile {
+	switch r.rotation {
+	case RotateHeadersPerRequest:
+		return r.profiles[rand.Intn(len(r.profiles))]
+	case RotateHeadersPerHost:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		p, ok := r.byHost[host]
+		if !ok {
+			p = r.profiles[rand.Intn(len(r.profiles))]
+			r.byHost[host] = p
+		}
+		return p
+	}
+	return r.fixed
+}
@@ -0,0 +1,66 @@
+package translator
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestHeaderProfiles(t *testing.T) {
+	var mu sync.Mutex
+	var seen []http.Header
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Clone())
+		mu.Unlock()
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	})
+
+	trans := newTestTranslator(t, h, Config{HeaderProfiles: BrowserProfiles, HeaderRotation: RotateHeadersPerRequest})
+	for i := 0; i < 20; i++ {
+		if _, err := trans.Translate("hello", "en", "es"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	agents := map[string]bool{}
+	for _, header := range seen {
+		if n := len(header.Values("User-Agent")); n != 1 {
+			t.Fatalf("%d User-Agent headers sent", n)
+		}
+		if header.Get("Accept-Language") == "" {
+			t.Fatal("Accept-Language not sent")
+		}
+		agents[header.Get("User-Agent")] = true
+	}
+	if len(agents) < 2 {
+		t.Errorf("per request rotation used %d user agents", len(agents))
+	}
+
+	seen = nil
+	profiles := []HeaderProfile{{UserAgent: "a", Referer: "https://translate.google.com/"}, {UserAgent: "b"}}
+	trans = newTestTranslator(t, h, Config{HeaderProfiles: profiles})
+	for i := 0; i < 5; i++ {
+		trans.Translate("hello", "en", "es")
+	}
+	for _, header := range seen {
+		if header.Get("User-Agent") != seen[0].Get("User-Agent") {
+			t.Fatal("per client rotation changed the user agent")
+		}
+	}
+}
+
+func TestHeaderRotator_PerHost(t *testing.T) {
+	r := newHeaderRotator(BrowserProfiles, RotateHeadersPerHost)
+	for _, host := range []string{"translate.google.com", "translate.google.de"} {
+		first := r.profile(host)
+		for i := 0; i < 10; i++ {
+			if r.profile(host) != first {
+				t.Fatalf("profile of %s changed", host)
+			}
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package translator
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlAttributes are the attributes holding text shown to users.
+var htmlAttributes = map[string]bool{"alt": true, "title": true, "placeholder": true}
+
+// htmlSkipped are the elements whose content is never translated.
+var htmlSkipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Code: true, atom.Kbd: true, atom.Samp: true,
+}
+
+// htmlInline are the elements translated as part of the surrounding text.
+var htmlInline = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Bdi: true, atom.Bdo: true, atom.Br: true,
+	atom.Cite: true, atom.Code: true, atom.Data: true, atom.Del: true, atom.Dfn: true, atom.Em: true,
+	atom.Font: true, atom.I: true, atom.Img: true, atom.Ins: true, atom.Kbd: true, atom.Label: true,
+	atom.Mark: true, atom.Q: true, atom.S: true, atom.Samp: true, atom.Small: true, atom.Span: true,
+	atom.Strong: true, atom.Sub: true, atom.Sup: true, atom.Time: true, atom.U: true, atom.Var: true,
+	atom.Wbr: true,
+}
+
+// htmlDocument matches input that is a whole document rather than a fragment.
+var htmlDocument = regexp.MustCompile(`(?i)^\s*(<!--.*?-->\s*)*<(!doctype|html|head|body)[\s>]`)
+
+// htmlRun is an inline run of an HTML element: consecutive text and inline
+// element children, translated together.
+type htmlRun struct {
+	parent *html.Node
+	nodes  []*html.Node // children of parent making up the run
+	parts  []part
+	tags   []*html.Node // formatting elements and verbatim nodes by part id
+}
+
+// TranslateHTML translates the text of an HTML document or fragment, keeping
+// its markup.
+//
+// Text nodes are translated together with the inline elements around them, so
+// that a sentence containing a link or bold text is translated whole and the
+// formatting lands on the matching words. The alt, title and placeholder
+// attributes are translated too. The content of script, style, code, kbd and
+// samp elements and of elements marked translate="no" or class="notranslate"
+// is left untouched. The lang attribute of the html element is set to dest.
+//
+// The result is re-serialised from the parsed tree, so it is valid HTML but
+// not byte for byte identical to the input outside the translated text.
+//
+// Parameters:
+//   - ctx: The context of the translation.
+//   - doc: The HTML document or fragment to translate.
+//   - src: The source language code (e.g., "en" for English or "auto").
+//   - dest: The destination language code (e.g., "es" for Spanish).
+//
+// Returns:
+//   - string: The translated HTML.
+//   - error: An error if the document cannot be parsed or a translation fails.
+//
+// Example Usage:
+//
+//	translated, err := translator.TranslateHTML(ctx, `<p>Hello <b>world</b>!</p>`, "en", "es")
+func (a *Translator) TranslateHTML(ctx context.Context, doc, src, dest string) (string, error) {
+	var nodes []*html.Node
+	if htmlDocument.MatchString(doc) {
+		root, err := html.Parse(strings.NewReader(doc))
+		if err != nil {
+			return "", err
+		}
+		nodes = []*html.Node{root}
+	} else {
+		body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+		var err error
+		if nodes, err = html.ParseFragment(strings.NewReader(doc), body); err != nil {
+			return "", err
+		}
+		for _, n := range nodes {
+			body.AppendChild(n)
+		}
+		nodes = []*html.Node{body}
+	}
+
+	var runs []*htmlRun
+	var attrs []*html.Attribute
+	for _, n := range nodes {
+		collectHTML(n, &runs, &attrs, dest)
+	}
+
+	texts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		texts[i] = attr.Val
+	}
+	translated, err := a.TranslateTexts(ctx, texts, src, dest)
+	if err != nil {
+		return "", err
+	}
+	for i, attr := range attrs {
+		attr.Val = translated[i]
+	}
+
+	parts := make([][]part, len(runs))
+	for i, run := range runs {
+		parts[i] = run.parts
+	}
+	if parts, err = a.translateRuns(ctx, parts, src, dest); err != nil {
+		return "", err
+	}
+	for i, run := range runs {
+		run.replace(parts[i])
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		if n.Type == html.DocumentNode {
+			err = html.Render(&b, n)
+		} else {
+			for c := n.FirstChild; c != nil && err == nil; c = c.NextSibling {
+				err = html.Render(&b, c)
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// collectHTML gathers the inline runs and translatable attributes below n.
+func collectHTML(n *html.Node, runs *[]*htmlRun, attrs *[]*html.Attribute, dest string) {
+	if n.Type == html.ElementNode {
+		if skipHTML(n) {
+			return
+		}
+		if n.DataAtom == atom.Html {
+			setAttribute(n, "lang", dest)
+		}
+		collectAttributes(n, attrs)
+	}
+
+	var run *htmlRun
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode || inlineHTML(c) {
+			if run == nil {
+				run = &htmlRun{parent: n}
+				*runs = append(*runs, run)
+			}
+			run.nodes = append(run.nodes, c)
+			run.add(c, attrs)
+			continue
+		}
+		run = nil
+		if c.Type == html.ElementNode || c.Type == html.DocumentNode {
+			collectHTML(c, runs, attrs, dest)
+		}
+	}
+}
+
+// add appends the parts of n, a child or descendant of the run's nodes.
+func (r *htmlRun) add(n *html.Node, attrs *[]*html.Attribute) {
+	switch {
+	case n.Type == html.TextNode:
+		r.parts = append(r.parts, part{kind: partText, text: n.Data})
+	case n.Type != html.ElementNode || skipHTML(n) || n.FirstChild == nil:
+		if n.Type == html.ElementNode && !skipHTML(n) {
+			collectAttributes(n, attrs)
+		}
+		r.parts = append(r.parts, part{kind: partAtom, id: len(r.tags)})
+		r.tags = append(r.tags, n)
+	default:
+		collectAttributes(n, attrs)
+		id := len(r.tags)
+		r.tags = append(r.tags, n)
+		r.parts = append(r.parts, part{kind: partOpen, id: id})
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.add(c, attrs)
+		}
+		r.parts = append(r.parts, part{kind: partClose, id: id})
+	}
+}
+
+// replace swaps the nodes of the run for the ones built from translated parts.
+func (r *htmlRun) replace(parts []part) {
+	next := r.nodes[len(r.nodes)-1].NextSibling
+	var built []*html.Node
+	var stack []*html.Node
+	emit := func(n *html.Node) {
+		if len(stack) > 0 {
+			stack[len(stack)-1].AppendChild(n)
+		} else {
+			built = append(built, n)
+		}
+	}
+
+	// Formatting elements are rebuilt around the new content, so their text
+	// nodes must be cleared before reuse; verbatim nodes move as they are.
+	for _, p := range parts {
+		switch p.kind {
+		case partOpen:
+			tag := r.tags[p.id]
+			for tag.FirstChild != nil {
+				tag.RemoveChild(tag.FirstChild)
+			}
+			if tag.Parent != nil {
+				tag.Parent.RemoveChild(tag)
+			}
+			emit(tag)
+			stack = append(stack, tag)
+		case partClose:
+			stack = stack[:len(stack)-1]
+		case partAtom:
+			tag := r.tags[p.id]
+			if tag.Parent != nil {
+				tag.Parent.RemoveChild(tag)
+			}
+			emit(tag)
+		case partText:
+			emit(&html.Node{Type: html.TextNode, Data: p.text})
+		}
+	}
+
+	for _, n := range r.nodes {
+		if n.Parent == r.parent {
+			r.parent.RemoveChild(n)
+		}
+	}
+	for _, n := range built {
+		r.parent.InsertBefore(n, next)
+	}
+}
+
+// inlineHTML reports whether n is translated as part of the surrounding text:
+// an inline element holding only text and inline elements, or a comment.
+func inlineHTML(n *html.Node) bool {
+	switch n.Type {
+	case html.CommentNode:
+		return true
+	case html.ElementNode:
+		if !htmlInline[n.DataAtom] {
+			return false
+		}
+		if skipHTML(n) {
+			return true
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.TextNode && !inlineHTML(c) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// skipHTML reports whether the content of element n is left untouched.
+func skipHTML(n *html.Node) bool {
+	if htmlSkipped[n.DataAtom] {
+		return true
+	}
+	for _, attr := range n.Attr {
+		switch {
+		case attr.Key == "translate" && strings.EqualFold(attr.Val, "no"):
+			return true
+		case attr.Key == "class" && strings.Contains(" "+attr.Val+" ", " notranslate "):
+			return true
+		}
+	}
+	return false
+}
+
+func collectAttributes(n *html.Node, attrs *[]*html.Attribute) {
+	for i := range n.Attr {
+		if n.Attr[i].Namespace == "" && htmlAttributes[n.Attr[i].Key] {
+			*attrs = append(*attrs, &n.Attr[i])
+		}
+	}
+}
+
+func setAttribute(n *html.Node, key, val string) {
+	for i := range n.Attr {
+		if n.Attr[i].Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
@@ -0,0 +1,68 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTranslateHTML(t *testing.T) {
+	var hits atomic.Int32
+	trans := newTestTranslator(t, upperHandler(&hits, false))
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			"fragment",
+			`<p>Hello <b>big <i>world</i></b>, see <a href="/docs" title="the docs">the docs</a>.</p><img src="a.png" alt="a cat">`,
+			`<p>HELLO <b>BIG <i>WORLD</i></b>, SEE <a href="/docs" title="THE DOCS">THE DOCS</a>.</p><img src="a.png" alt="A CAT"/>`,
+		},
+		{
+			"skipped",
+			`<div><p>Run <code>go test</code> now</p><script>var x = "text";</script><p translate="no">Brand</p><span class="x notranslate">Name</span> and more<br>text</div>`,
+			`<div><p>RUN <code>go test</code> NOW</p><script>var x = "text";</script><p translate="no">Brand</p><span class="x notranslate">Name</span> AND MORE<br/>TEXT</div>`,
+		},
+		{
+			"document",
+			`<!DOCTYPE html><html lang="en"><head><title>My page</title></head><body><input placeholder="Search"><!-- note --><ul><li>One</li><li>Two</li></ul></body></html>`,
+			`<!DOCTYPE html><html lang="es"><head><title>MY PAGE</title></head><body><input placeholder="SEARCH"/><!-- note --><ul><li>ONE</li><li>TWO</li></ul></body></html>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trans.TranslateHTML(context.Background(), tt.in, "en", "es")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranslateHTML_LostMarkers(t *testing.T) {
+	// The service drops the markers: every text is then translated on its own.
+	markers := regexp.MustCompile(`\[\[[^\]]*\]\]`)
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		q := strings.ToUpper(markers.ReplaceAllString(r.URL.Query().Get("q"), ""))
+		json.NewEncoder(w).Encode(sentences{Sentences: []sentence{{Trans: q, Orig: q}}})
+	}))
+
+	got, err := trans.TranslateHTML(context.Background(), `<p>Hello <b>world</b>!</p>`, "en", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<p>HELLO <b>WORLD</b>!</p>`; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
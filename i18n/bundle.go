@@ -0,0 +1,212 @@
+// Package i18n translates JSON locale files such as those of i18next or
+// vue-i18n: nested objects whose string leaves are the messages.
+//
+// Parse reads a bundle keeping the order of its keys; Translate translates its
+// strings, keeping the messages of an existing translation; WriteTo writes it
+// back with the indentation it was read with:
+//
+//	b, err := i18n.Parse(source)
+//	if err != nil {
+//	  return err
+//	}
+//	if _, err := i18n.Translate(ctx, t, b, existing, "en", "de"); err != nil {
+//	  return err
+//	}
+//	_, err = b.WriteTo(out)
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type kind int
+
+const (
+	kindObject kind = iota
+	kindArray
+	kindString
+	kindOther // numbers, booleans and null
+)
+
+// value is a JSON value. Objects keep the order of their keys.
+type value struct {
+	kind   kind
+	keys   []string
+	fields map[string]*value
+	items  []*value
+	str    string
+	other  interface{} // json.Number, bool or nil
+}
+
+// Bundle is a parsed locale file.
+type Bundle struct {
+	root   *value
+	indent string // indentation of the file, empty when compact
+	eol    bool   // the file ended with a line break
+}
+
+// Parse reads a JSON locale file.
+func Parse(r io.Reader) (*Bundle, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("i18n: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("i18n: data after the top-level value")
+	}
+	return &Bundle{root: root, indent: detectIndent(data), eol: bytes.HasSuffix(bytes.TrimRight(data, " \t"), []byte("\n"))}, nil
+}
+
+func decode(dec *json.Decoder) (*value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			v := &value{kind: kindArray}
+			for dec.More() {
+				item, err := decode(dec)
+				if err != nil {
+					return nil, err
+				}
+				v.items = append(v.items, item)
+			}
+			_, err := dec.Token()
+			return v, err
+		}
+		v := &value{kind: kindObject, fields: map[string]*value{}}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := tok.(string)
+			field, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			v.set(key, field)
+		}
+		_, err := dec.Token()
+		return v, err
+	case string:
+		return &value{kind: kindString, str: tok}, nil
+	default:
+		return &value{kind: kindOther, other: tok}, nil
+	}
+}
+
+// set sets a member of an object, keeping the position of an existing key.
+func (v *value) set(key string, field *value) {
+	if _, ok := v.fields[key]; !ok {
+		v.keys = append(v.keys, key)
+	}
+	v.fields[key] = field
+}
+
+// detectIndent returns the indentation of the first indented line of data,
+// empty when the value is on a single line.
+func detectIndent(data []byte) string {
+	i := bytes.IndexByte(data, '\n')
+	if i < 0 || len(bytes.TrimSpace(data[i:])) == 0 {
+		return ""
+	}
+	line := data[i+1:]
+	indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+	if len(indent) == 0 {
+		return "  "
+	}
+	return string(indent)
+}
+
+// WriteTo writes the bundle as JSON, indented like the file it was parsed from.
+func (b *Bundle) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if err := b.root.write(&buf, b.indent, 0); err != nil {
+		return 0, err
+	}
+	if b.eol {
+		buf.WriteByte('\n')
+	}
+	return buf.WriteTo(w)
+}
+
+func (v *value) write(buf *bytes.Buffer, indent string, depth int) error {
+	newline := func(depth int) {
+		if indent != "" {
+			buf.WriteString("\n" + strings.Repeat(indent, depth))
+		}
+	}
+	colon := ":"
+	if indent != "" {
+		colon = ": "
+	}
+
+	switch v.kind {
+	case kindObject:
+		buf.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			writeString(buf, key)
+			buf.WriteString(colon)
+			if err := v.fields[key].write(buf, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(v.keys) > 0 {
+			newline(depth)
+		}
+		buf.WriteByte('}')
+	case kindArray:
+		buf.WriteByte('[')
+		for i, item := range v.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := item.write(buf, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(v.items) > 0 {
+			newline(depth)
+		}
+		buf.WriteByte(']')
+	case kindString:
+		writeString(buf, v.str)
+	default:
+		data, err := json.Marshal(v.other)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// writeString writes s as a JSON string without escaping HTML characters,
+// which messages often hold.
+func writeString(buf *bytes.Buffer, s string) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
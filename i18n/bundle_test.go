@@ -0,0 +1,34 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	for _, in := range []string{
+		"{\n    \"z\": \"last <b>first</b>\",\n    \"a\": {\n        \"n\": 1.50,\n        \"list\": [\n            \"x\",\n            true,\n            null\n        ],\n        \"empty\": {}\n    }\n}\n",
+		"{\n\t\"b\": \"tab\",\n\t\"a\": []\n}",
+		`{"compact":{"b":"1","a":"2"}}`,
+	} {
+		b, err := Parse(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out strings.Builder
+		if _, err := b.WriteTo(&out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != in {
+			t.Errorf("got %q, want %q", out.String(), in)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, in := range []string{"", "{", `{"a": }`, `{"a": 1} {}`} {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("Parse(%q): no error", in)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package i18n
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+// placeholders matches what messages hold besides text: interpolations such
+// as "{{name}}", "{{- html}}", "{count}" and "%{name}", i18next nesting such as
+// "$t(key)", vue-i18n linked messages such as "@:key" and "@.lower:key", and
+// HTML tags.
+var placeholders = regexp.MustCompile(`\{\{[^{}]*\}\}|%?\{[^{}]*\}|\$t\([^()]*\)|@(?:\.\w+)?:(?:\([^()]*\)|[\w.-]+)|<[^<>]+>`)
+
+// icuHead matches the start of an ICU plural or select argument, up to its
+// first case.
+var icuHead = regexp.MustCompile(`^\{\s*[^{},\s]+\s*,\s*(?:plural|selectordinal|select)\s*,`)
+
+// icuCase matches the selector of a case of an ICU argument and the brace
+// opening its message.
+var icuCase = regexp.MustCompile(`^\s*(?:offset:\s*\d+\s+)?(?:=\d+|[\w-]+)\s*\{`)
+
+// icuEnd matches the brace closing an ICU argument.
+var icuEnd = regexp.MustCompile(`^\s*\}`)
+
+// Options sets what Translate keeps of the messages.
+type Options struct {
+	// Protect matches the parts of messages kept as they are. Defaults to the
+	// interpolations, nesting and linked messages of i18next and vue-i18n, and
+	// HTML tags.
+	Protect *regexp.Regexp
+}
+
+// Translate translates the string leaves of b from src to dest in place and
+// returns how many were translated.
+//
+// existing is the current translation, if any: its messages are kept instead
+// of translated for the keys it already has, and its keys missing from b are
+// kept at the end of their object. Keys, nesting and order otherwise follow b.
+//
+// The messages of ICU plural and select arguments, such as "{count, plural,
+// one {# file} other {# files}}", are translated one by one, keeping the
+// argument itself and the "#" standing for the number.
+func Translate(ctx context.Context, t translator.ProtectedTranslator, b, existing *Bundle, src, dest string, opts ...Options) (int, error) {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Protect == nil {
+		o.Protect = placeholders
+	}
+
+	var leaves []*value
+	if existing == nil {
+		collect(b.root, o.Protect, &leaves)
+	} else {
+		b.root = merge(b.root, existing.root, o.Protect, &leaves)
+	}
+	if len(leaves) == 0 {
+		return 0, nil
+	}
+
+	protect := regexp.MustCompile(`(?:` + o.Protect.String() + `)|#`)
+	segments := make([][]icuSegment, len(leaves))
+	var texts []string
+	for i, leaf := range leaves {
+		segments[i] = splitICU(leaf.str)
+		for _, seg := range segments[i] {
+			if !seg.frame {
+				texts = append(texts, seg.text)
+			}
+		}
+	}
+	translated, err := t.TranslateProtected(ctx, texts, protect, src, dest)
+	if err != nil {
+		return 0, err
+	}
+	for i, leaf := range leaves {
+		var str strings.Builder
+		for _, seg := range segments[i] {
+			if !seg.frame {
+				seg.text, translated = translated[0], translated[1:]
+			}
+			str.WriteString(seg.text)
+		}
+		leaf.str = str.String()
+	}
+	return len(leaves), nil
+}
+
+// icuSegment is a piece of a message: text to translate, or a frame of an ICU
+// argument kept as it is.
+type icuSegment struct {
+	text  string
+	frame bool
+}
+
+// splitICU splits a message into the text of its ICU plural and select
+// arguments and their frames: the argument name and type, the selectors and
+// the braces. Other arguments are left in the text. A message without such
+// arguments, or whose braces do not balance, is a single text.
+func splitICU(s string) []icuSegment {
+	if !strings.Contains(s, ",") {
+		return []icuSegment{{text: s}}
+	}
+	p := &icuParser{s: s}
+	if !p.message(false) || len(p.segments) == 1 {
+		return []icuSegment{{text: s}}
+	}
+	return p.segments
+}
+
+type icuParser struct {
+	s        string
+	i        int
+	segments []icuSegment
+}
+
+// add appends s to the last segment when of the same kind.
+func (p *icuParser) add(s string, frame bool) {
+	if n := len(p.segments); n > 0 && p.segments[n-1].frame == frame {
+		p.segments[n-1].text += s
+		return
+	}
+	p.segments = append(p.segments, icuSegment{s, frame})
+}
+
+// message reads a message up to the brace closing it when nested, or to the
+// end of the string.
+func (p *icuParser) message(nested bool) bool {
+	for p.i < len(p.s) {
+		switch p.s[p.i] {
+		case '{':
+			if head := icuHead.FindString(p.s[p.i:]); head != "" {
+				p.add(head, true)
+				p.i += len(head)
+				if !p.cases() {
+					return false
+				}
+				continue
+			}
+			// Other arguments are text, kept by the placeholders.
+			depth, j := 0, p.i
+			for ; j < len(p.s); j++ {
+				if p.s[j] == '{' {
+					depth++
+				} else if p.s[j] == '}' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			if j == len(p.s) {
+				return false
+			}
+			p.add(p.s[p.i:j+1], false)
+			p.i = j + 1
+		case '}':
+			return nested
+		default:
+			p.add(p.s[p.i:p.i+1], false)
+			p.i++
+		}
+	}
+	return !nested
+}
+
+// cases reads the cases of an ICU argument and the brace closing it.
+func (p *icuParser) cases() bool {
+	for {
+		if end := icuEnd.FindString(p.s[p.i:]); end != "" {
+			p.add(end, true)
+			p.i += len(end)
+			return true
+		}
+		open := icuCase.FindString(p.s[p.i:])
+		if open == "" {
+			return false
+		}
+		p.add(open, true)
+		p.i += len(open)
+		if !p.message(true) {
+			return false
+		}
+		p.add("}", true)
+		p.i++
+	}
+}
+
+// merge returns v with the values of ex in place of its own, collecting the
+// strings left to translate. Objects are merged key by key.
+func merge(v, ex *value, protect *regexp.Regexp, leaves *[]*value) *value {
+	if v.kind != kindObject || ex.kind != kindObject {
+		return ex
+	}
+	for _, key := range v.keys {
+		if field, ok := ex.fields[key]; ok {
+			v.fields[key] = merge(v.fields[key], field, protect, leaves)
+		} else {
+			collect(v.fields[key], protect, leaves)
+		}
+	}
+	for _, key := range ex.keys {
+		if _, ok := v.fields[key]; !ok {
+			v.set(key, ex.fields[key])
+		}
+	}
+	return v
+}
+
+// collect appends the strings below v holding text besides the substrings
+// matched by protect to leaves.
+func collect(v *value, protect *regexp.Regexp, leaves *[]*value) {
+	switch v.kind {
+	case kindObject:
+		for _, key := range v.keys {
+			collect(v.fields[key], protect, leaves)
+		}
+	case kindArray:
+		for _, item := range v.items {
+			collect(item, protect, leaves)
+		}
+	case kindString:
+		for _, seg := range splitICU(v.str) {
+			if !seg.frame && strings.TrimSpace(strings.ReplaceAll(protect.ReplaceAllString(seg.text, ""), "#", "")) != "" {
+				*leaves = append(*leaves, v)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lcapuano-app/go-googletrans/internal/translatortest"
+)
+
+const source = `{
+  "greeting": "Hello {{name}}, you have {count} messages",
+  "nav": {
+    "home": "Home",
+    "about": "About @:nav.home",
+    "nested": "See $t(nav.home) or <a href=\"/x\">here</a>"
+  },
+  "list": ["one", "two"],
+  "placeholder": "{{name}}",
+  "max": 3
+}
+`
+
+func TestTranslate(t *testing.T) {
+	b, err := Parse(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &translatortest.Fake{}
+	n, err := Translate(context.Background(), fake, b, nil, "en", "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 6 {
+		t.Fatalf("translated %d strings, want 6", n)
+	}
+	var out strings.Builder
+	b.WriteTo(&out)
+	want := `{
+  "greeting": "HELLO {{name}}, YOU HAVE {count} MESSAGES",
+  "nav": {
+    "home": "HOME",
+    "about": "ABOUT @:nav.home",
+    "nested": "SEE $t(nav.home) OR <a href=\"/x\">HERE</a>"
+  },
+  "list": [
+    "ONE",
+    "TWO"
+  ],
+  "placeholder": "{{name}}",
+  "max": 3
+}
+`
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestTranslate_Existing(t *testing.T) {
+	b, err := Parse(strings.NewReader(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing, err := Parse(strings.NewReader(`{"nav": {"home": "Startseite", "legacy": "Alt"}, "list": ["eins"], "greeting": ""}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &translatortest.Fake{}
+	n, err := Translate(context.Background(), fake, b, existing, "en", "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("translated %d strings, want 2: %q", n, fake.Calls)
+	}
+	var out strings.Builder
+	b.WriteTo(&out)
+	want := `{
+  "greeting": "",
+  "nav": {
+    "home": "Startseite",
+    "about": "ABOUT @:nav.home",
+    "nested": "SEE $t(nav.home) OR <a href=\"/x\">HERE</a>",
+    "legacy": "Alt"
+  },
+  "list": [
+    "eins"
+  ],
+  "placeholder": "{{name}}",
+  "max": 3
+}
+`
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestTranslate_ICU(t *testing.T) {
+	b, err := Parse(strings.NewReader(`{
+  "inbox": "You have {count, plural, =0 {no messages} one {# message} other {# messages from {name}}}.",
+  "shared": "{gender, select, female {{n, plural, one {She shared # photo} other {She shared # photos}}} other {They shared {n} photos}}",
+  "only": "{count, plural, one {#} other {#}}",
+  "simple": "Hello {name}, {n, number} left"
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &translatortest.Fake{}
+	n, err := Translate(context.Background(), fake, b, nil, "en", "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("translated %d strings, want 3: %q", n, fake.Calls)
+	}
+	var out strings.Builder
+	b.WriteTo(&out)
+	want := `{
+  "inbox": "YOU HAVE {count, plural, =0 {NO MESSAGES} one {# MESSAGE} other {# MESSAGES FROM {name}}}.",
+  "shared": "{gender, select, female {{n, plural, one {SHE SHARED # PHOTO} other {SHE SHARED # PHOTOS}}} other {THEY SHARED {n} PHOTOS}}",
+  "only": "{count, plural, one {#} other {#}}",
+  "simple": "HELLO {name}, {n, number} LEFT"
+}`
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+	// Each message of an argument is a text of its own.
+	if got := fake.Calls[0]; !contains(got, "no messages") || !contains(got, "She shared # photo") {
+		t.Fatalf("got texts %q", got)
+	}
+}
+
+func contains(texts []string, s string) bool {
+	for _, text := range texts {
+		if text == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Operation names the kind of upstream request being made.
+type Operation string
+
+const (
+	OpTokenRefresh Operation = "token_refresh" // homepage fetch to refresh the TKK
+	OpTranslate    Operation = "translate"     // translation request
+	OpDetect       Operation = "detect"        // language detection request
+)
+
+// RequestInfo describes a completed upstream request.
+type RequestInfo struct {
+	Operation     Operation
+	Host          string        // host the request was sent to
+	Src           string        // source language, empty for token refreshes
+	Dest          string        // destination language, empty for token refreshes
+	StatusCode    int           // status code of the response, 0 if none was received
+	Err           error         // error of the request, nil on success
+	Latency       time.Duration // time from sending the request to reading the whole body
+	RequestBytes  int64         // size of the request URL and body
+	ResponseBytes int64         // size of the response body that was read
+	Retry         int           // 0 for the first attempt of a call, 1 for its retry and so on
+}
+
+// Instrumentation receives a RequestInfo for every upstream request made by a Translator.
+// ObserveRequest is called synchronously on the request path, so it should not block.
+type Instrumentation interface {
+	ObserveRequest(info RequestInfo)
+}
+
+// upstream holds the observers shared by every request of a Translator and its token service.
+type upstream struct {
+	instr   Instrumentation
+	tracer  trace.Tracer
+	logger  *slog.Logger
+	logURLs bool
+
+	proxies     atomic.Pointer[proxyPool] // nil when requests are sent directly
+	proxyPolicy proxyPolicy
+	breaker     *breaker // nil when disabled
+}
+
+func newUpstream(c Config) *upstream {
+	u := &upstream{
+		instr:       c.Instrumentation,
+		tracer:      newTracer(c.TracerProvider),
+		logger:      newLogger(c.Logger),
+		logURLs:     c.LogRequestURLs,
+		proxyPolicy: newProxyPolicy(c),
+	}
+	u.breaker = newBreaker(c.Breaker, u.logger)
+	return u
+}
+
+// upstreamCall identifies the call an upstream request belongs to.
+type upstreamCall struct {
+	op    Operation
+	src   string
+	dest  string
+	retry int
+	probe bool // a health check of the host, kept out of its circuit and the instrumentation
+}
+
+// send performs req and returns the body of a 200 response. When the client
+// has a cookie jar, a consent page is accepted by setting the consent cookies
+// and the request is sent once more.
+func (u *upstream) send(client *http.Client, req *http.Request, call upstreamCall) ([]byte, error) {
+	body, err := u.attempt(client, req, call)
+	var blocked *BlockedError
+	if client.Jar == nil || !errors.As(err, &blocked) || blocked.Kind != BlockConsent {
+		return body, err
+	}
+	acceptConsent(client.Jar, req.URL)
+	u.logger.LogAttrs(req.Context(), slog.LevelInfo, "accepted consent page", slog.String("host", req.URL.Host))
+	return u.attempt(client, req, call)
+}
+
+// attempt performs req, checks the response for errors and block pages and
+// returns the body of a 200 response. The request is refused while the circuit
+// of its host and operation is open, goes through a proxy of the pool, if any,
+// is traced as a child span of the request context and, once sent, reported to
+// the instrumentation. Probes bypass the circuit and the instrumentation.
+func (u *upstream) attempt(client *http.Client, req *http.Request, call upstreamCall) ([]byte, error) {
+	ctx, span := u.tracer.Start(req.Context(), "translator.http_attempt",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attrOperation.String(string(call.op)),
+			attrHost.String(req.URL.Host),
+			attrRetry.Int(call.retry),
+		))
+	req = req.WithContext(ctx)
+
+	start := time.Now()
+	info := RequestInfo{
+		Operation:    call.op,
+		Host:         req.URL.Host,
+		Src:          call.src,
+		Dest:         call.dest,
+		RequestBytes: int64(len(req.URL.String())),
+		Retry:        call.retry,
+	}
+	if req.ContentLength > 0 {
+		info.RequestBytes += req.ContentLength
+	}
+
+	pool := u.proxies.Load()
+	var proxy *proxyState
+	var allowed, trial, sent bool
+	var counter countingReader
+	body, err := func() ([]byte, error) {
+		var err error
+		if proxy, err = pool.pick(req.URL.Host); err != nil {
+			return nil, err
+		}
+		if !call.probe {
+			if trial, err = u.breaker.allow(req.URL.Host, call.op); err != nil {
+				return nil, err
+			}
+			allowed = true
+		}
+		if proxy != nil {
+			req = req.WithContext(context.WithValue(req.Context(), proxyKey{}, proxy.url))
+		}
+
+		sent = true
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, redactURLError(err)
+		}
+		defer resp.Body.Close()
+		info.StatusCode = resp.StatusCode
+		counter.r = resp.Body
+		resp.Body = io.NopCloser(&counter)
+
+		// Check if the response has a status code of 200 (OK).
+		if err := checkResponse(req.URL.Host, resp); err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		// Captcha and consent pages may be served with status 200 after a redirect.
+		return body, checkBlocked(req.URL.Host, resp, body)
+	}()
+
+	if info.StatusCode != 0 {
+		span.SetAttributes(attrStatusCode.Int(info.StatusCode))
+	}
+	endSpan(span, err)
+	pool.report(ctx, proxy, err)
+	if allowed {
+		u.breaker.record(ctx, req.URL.Host, call.op, trial, err)
+	}
+
+	info.Latency = time.Since(start)
+	info.ResponseBytes = counter.n
+	info.Err = err
+	if !sent {
+		// The proxy pool or the circuit breaker refused the request: nothing
+		// reached the host, so there is no request to observe.
+		u.logger.LogAttrs(ctx, slog.LevelDebug, "upstream request refused",
+			slog.String("operation", string(info.Operation)),
+			slog.String("host", info.Host),
+			slog.Any("error", err))
+		return body, err
+	}
+	u.log(ctx, req, info)
+	if u.instr != nil && !call.probe {
+		u.instr.ObserveRequest(info)
+	}
+	return body, err
+}
+
+// log reports a completed upstream request. The text being translated is never
+// logged; the request URL, which contains it, only when LogRequestURLs is set.
+func (u *upstream) log(ctx context.Context, req *http.Request, info RequestInfo) {
+	attrs := []slog.Attr{
+		slog.String("operation", string(info.Operation)),
+		slog.String("host", info.Host),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("latency", info.Latency),
+		slog.Int("retry", info.Retry),
+	}
+	if u.logURLs {
+		attrs = append(attrs, slog.String("url", req.URL.String()))
+	}
+
+	var blocked *BlockedError
+	switch {
+	case info.Err == nil:
+		attrs = append(attrs, slog.Int64("bytes", info.ResponseBytes))
+		u.logger.LogAttrs(ctx, slog.LevelDebug, "upstream request", attrs...)
+	case errors.As(info.Err, &blocked):
+		attrs = append(attrs, slog.String("kind", string(blocked.Kind)), slog.String("page", blocked.URL))
+		u.logger.LogAttrs(ctx, slog.LevelWarn, "upstream request blocked", attrs...)
+	default:
+		attrs = append(attrs, slog.Any("error", info.Err))
+		u.logger.LogAttrs(ctx, slog.LevelWarn, "upstream request failed", attrs...)
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,87 @@
+package translator
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	infos []RequestInfo
+}
+
+func (r *recorder) ObserveRequest(info RequestInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.infos = append(r.infos, info)
+}
+
+func TestInstrumentation(t *testing.T) {
+	blocked := true
+	rec := &recorder{}
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path != "/translate_a/single":
+			w.Write([]byte("<html>homepage</html>"))
+		case blocked:
+			blocked = false
+			w.Write([]byte(sorryPage))
+		default:
+			w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+		}
+	}), Config{
+		Instrumentation: rec,
+		OnBlocked:       func(*Translator, *BlockedError) bool { return true },
+	})
+
+	if _, err := trans.Translate("hello", "en", "es"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		op    Operation
+		retry int
+		err   bool
+	}{
+		{OpTokenRefresh, 0, false},
+		{OpTranslate, 0, true},
+		{OpTokenRefresh, 0, false},
+		{OpTranslate, 1, false},
+	}
+	if len(rec.infos) != len(want) {
+		t.Fatalf("observed %d requests, want %d: %+v", len(rec.infos), len(want), rec.infos)
+	}
+	for i, w := range want {
+		info := rec.infos[i]
+		if info.Operation != w.op || info.Retry != w.retry || (info.Err != nil) != w.err {
+			t.Errorf("request %d: %+v, want %+v", i, info, w)
+		}
+		if info.Host != trans.GetHost() || info.StatusCode != http.StatusOK || info.ResponseBytes == 0 || info.RequestBytes == 0 {
+			t.Errorf("request %d: incomplete info %+v", i, info)
+		}
+	}
+	if last := rec.infos[3]; last.Src != "en" || last.Dest != "es" {
+		t.Errorf("language pair not reported: %+v", last)
+	}
+}
+
+func TestInstrumentation_OpenCircuit(t *testing.T) {
+	rec := &recorder{}
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	}), Config{Instrumentation: rec, Breaker: BreakerConfig{Failures: 1, CoolDown: time.Hour}})
+	host := trans.GetHost()
+	for _, op := range []Operation{OpTokenRefresh, OpTranslate} {
+		trans.up.breaker.circuits[breakerKey{host, op}] = &circuit{state: BreakerOpen, failures: 1, openedAt: time.Now()}
+	}
+
+	if _, err := trans.Translate("hello", "en", "es"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+	if len(rec.infos) != 0 {
+		t.Fatalf("observed requests that were never sent: %+v", rec.infos)
+	}
+}
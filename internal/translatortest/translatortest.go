@@ -0,0 +1,38 @@
+// Package translatortest provides a fake translator.ProtectedTranslator for
+// the tests of the packages translating file formats.
+package translatortest
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Fake translates by upper-casing the text outside the protected substrings.
+type Fake struct {
+	// Edit, if set, changes every translation, e.g. to mangle it the way the
+	// service sometimes does.
+	Edit func(text, translated string) string
+	// Calls holds the texts of every call, in order.
+	Calls [][]string
+}
+
+// TranslateProtected implements translator.ProtectedTranslator.
+func (f *Fake) TranslateProtected(_ context.Context, texts []string, protect *regexp.Regexp, _, _ string) ([]string, error) {
+	f.Calls = append(f.Calls, texts)
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		var b strings.Builder
+		last := 0
+		for _, m := range protect.FindAllStringIndex(text, -1) {
+			b.WriteString(strings.ToUpper(text[last:m[0]]) + text[m[0]:m[1]])
+			last = m[1]
+		}
+		b.WriteString(strings.ToUpper(text[last:]))
+		out[i] = b.String()
+		if f.Edit != nil {
+			out[i] = f.Edit(text, out[i])
+		}
+	}
+	return out, nil
+}
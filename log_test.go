@@ -0,0 +1,38 @@
+package translator
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		w.Write([]byte(`not json`))
+	})
+
+	for _, logURLs := range []bool{false, true} {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		trans := newTestTranslator(t, h, Config{Logger: logger, LogRequestURLs: logURLs})
+
+		if _, err := trans.Translate("secretword", "en", "es"); err == nil {
+			t.Fatal("expected a parse error")
+		}
+		out := buf.String()
+		for _, msg := range []string{"selected service host", "tkk not found on homepage", "upstream request", "failed to parse response"} {
+			if !strings.Contains(out, msg) {
+				t.Errorf("log does not contain %q:\n%s", msg, out)
+			}
+		}
+		if leaked := strings.Contains(out, "secretword"); leaked != logURLs {
+			t.Errorf("LogRequestURLs=%v, text logged=%v:\n%s", logURLs, leaked, out)
+		}
+	}
+}
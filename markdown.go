@@ -0,0 +1,664 @@
+package translator
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	mdFence       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdThematic    = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,}|=+[ \t]*)$`)
+	mdHeading     = regexp.MustCompile(`^( {0,3}#{1,6}[ \t]+)(.*?)((?:[ \t]+#+)?[ \t]*)$`)
+	mdListItem    = regexp.MustCompile(`^([ \t]*(?:[-+*]|\d{1,9}[.)])(?:[ \t]+|$)(?:\[[ xX]\][ \t]+)?)`)
+	mdListMarker  = regexp.MustCompile(`^[ \t]*(?:[-+*]|\d{1,9}[.)])`)
+	mdBlockquote  = regexp.MustCompile(`^(?: {0,3}>[ \t]?)+`)
+	mdRefDef      = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:`)
+	mdFootnote    = regexp.MustCompile(`^( {0,3}\[\^[^\]]+\]:[ \t]*)(.*)$`)
+	mdTableDelim  = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	mdFrontMatter = regexp.MustCompile(`^((?:title|description|summary)[ \t]*[:=][ \t]*)(.*?)([ \t]*)$`)
+	mdHTMLBlock   = regexp.MustCompile(`(?i)^ {0,3}<(?:(script|pre|style|textarea)(?:[\s>]|$)|(!--)|(\?)|(![a-z])|(!\[CDATA\[)|(/?(?:address|article|aside|blockquote|body|details|dialog|dd|div|dl|dt|fieldset|figcaption|figure|footer|form|h[1-6]|head|header|hr|html|iframe|legend|li|link|main|menu|nav|ol|p|param|section|summary|table|tbody|td|tfoot|th|thead|title|tr|ul)(?:[\s/>]|$))|(/?[a-z][a-z0-9-]*(?:\s[^>]*)?/?>\s*$))`)
+	mdInlineHTML  = regexp.MustCompile(`^<(?:[A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*|[^\s<>@]+@[^\s<>]+|/?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?|!--.*?--)>`)
+	mdEntity      = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	mdBareURL     = regexp.MustCompile(`^(?:https?://|www\.)[^\s<]*[^\s<.,:;!?"')\]*_~]`)
+)
+
+// mdLine is a line of a Markdown document without its line ending.
+type mdLine struct {
+	text, eol string
+}
+
+// mdMark is the markup around or making up a part of an inline run.
+type mdMark struct {
+	open, close string
+}
+
+type mdRun struct {
+	parts []part
+	marks []mdMark
+}
+
+// mdPiece is a piece of output: verbatim text, or a run to translate.
+type mdPiece struct {
+	text string
+	run  *mdRun
+}
+
+// mdWriter splits a document into verbatim pieces and translatable runs.
+type mdWriter struct {
+	pieces []mdPiece
+	runs   []*mdRun
+	refs   map[string]bool // link reference labels, normalised
+}
+
+// TranslateMarkdown translates the text of a Markdown document, keeping its
+// structure.
+//
+// Headings, paragraphs, list items, block quotes, table cells, footnotes and
+// the text of links and images are translated; emphasis and links inside a
+// paragraph are translated together with the surrounding text. Code blocks,
+// inline code, URLs, HTML, link reference definitions and everything else
+// that is not text is copied as is. In front matter only the values of the
+// title, description and summary keys are translated.
+//
+// Lines of a paragraph are joined into one, which renders the same.
+//
+// Parameters:
+//   - ctx: The context of the translation.
+//   - doc: The Markdown document to translate.
+//   - src: The source language code (e.g., "en" for English or "auto").
+//   - dest: The destination language code (e.g., "es" for Spanish).
+//
+// Returns:
+//   - string: The translated Markdown.
+//   - error: An error if a translation fails.
+//
+// Example Usage:
+//
+//	readme, _ := os.ReadFile("README.md")
+//	translated, err := translator.TranslateMarkdown(ctx, string(readme), "en", "es")
+func (a *Translator) TranslateMarkdown(ctx context.Context, doc, src, dest string) (string, error) {
+	var lines []mdLine
+	for _, l := range strings.SplitAfter(doc, "\n") {
+		if l == "" {
+			continue
+		}
+		text := strings.TrimRight(l, "\r\n")
+		lines = append(lines, mdLine{text, l[len(text):]})
+	}
+
+	w := &mdWriter{refs: map[string]bool{}}
+	for _, l := range lines {
+		if m := mdRefDef.FindStringSubmatch(l.text); m != nil {
+			w.refs[normalizeLabel(m[1])] = true
+		}
+	}
+	w.document(lines)
+
+	runs := make([][]part, len(w.runs))
+	for i, run := range w.runs {
+		runs[i] = run.parts
+	}
+	runs, err := a.translateRuns(ctx, runs, src, dest)
+	if err != nil {
+		return "", err
+	}
+	for i, run := range w.runs {
+		run.parts = runs[i]
+	}
+
+	var b strings.Builder
+	for _, p := range w.pieces {
+		if p.run == nil {
+			b.WriteString(p.text)
+			continue
+		}
+		for _, part := range p.run.parts {
+			switch part.kind {
+			case partText:
+				b.WriteString(part.text)
+			case partOpen, partAtom:
+				b.WriteString(p.run.marks[part.id].open)
+			case partClose:
+				b.WriteString(p.run.marks[part.id].close)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func (w *mdWriter) verbatim(s string) {
+	if s != "" {
+		w.pieces = append(w.pieces, mdPiece{text: s})
+	}
+}
+
+// text adds inline Markdown to translate, keeping the whitespace around it.
+func (w *mdWriter) text(s string) {
+	lead, trail := surroundingSpace(s)
+	if strings.TrimSpace(s) == "" {
+		w.verbatim(s)
+		return
+	}
+	w.verbatim(lead)
+	in := &mdInline{run: &mdRun{}, refs: w.refs}
+	in.parse(strings.TrimSpace(s))
+	in.flush()
+	w.pieces = append(w.pieces, mdPiece{run: in.run})
+	w.runs = append(w.runs, in.run)
+	w.verbatim(trail)
+}
+
+// document splits lines into blocks.
+func (w *mdWriter) document(lines []mdLine) {
+	i := w.frontMatter(lines)
+	var items []int // content columns of the open list items, innermost last
+	prevPara := false
+	for i < len(lines) {
+		l := lines[i]
+		quote := mdBlockquote.FindString(l.text)
+		rest := l.text[len(quote):]
+		if strings.TrimSpace(rest) == "" {
+			w.verbatim(l.text + l.eol)
+			prevPara = false
+			i++
+			continue
+		}
+		// Lazy continuation lines are taken by paragraph, so a line indented
+		// less than the content of a list item closes it.
+		indent := indentation(rest)
+		for len(items) > 0 && indent < items[len(items)-1] {
+			items = items[:len(items)-1]
+		}
+		base := 0
+		if len(items) > 0 {
+			base = items[len(items)-1]
+		}
+
+		switch {
+		case indent-base >= 4 && !prevPara:
+			// Indented code block.
+			w.verbatim(l.text + l.eol)
+			i++
+		case mdFence.MatchString(strings.TrimLeft(rest, " \t")):
+			i = w.fenced(lines, i)
+		case htmlBlockEnd(rest) != "":
+			i = w.htmlBlock(lines, i)
+		case mdThematic.MatchString(rest):
+			w.verbatim(l.text + l.eol)
+			i++
+		case mdFootnote.MatchString(rest):
+			m := mdFootnote.FindStringSubmatch(rest)
+			w.verbatim(quote + m[1])
+			w.text(m[2])
+			w.verbatim(l.eol)
+			i++
+		case mdRefDef.MatchString(rest):
+			w.verbatim(l.text + l.eol)
+			i++
+		case mdHeading.MatchString(rest):
+			m := mdHeading.FindStringSubmatch(rest)
+			w.verbatim(quote + m[1])
+			w.text(m[2])
+			w.verbatim(m[3] + l.eol)
+			i++
+		case strings.Contains(rest, "|") && i+1 < len(lines) && strings.Contains(lines[i+1].text, "-") && mdTableDelim.MatchString(lines[i+1].text[len(mdBlockquote.FindString(lines[i+1].text)):]):
+			i = w.table(lines, i)
+		default:
+			if mdListItem.MatchString(rest) {
+				items = append(items, listContent(rest))
+			}
+			i = w.paragraph(lines, i)
+			prevPara = true
+			continue
+		}
+		prevPara = false
+	}
+}
+
+// listContent returns the column at which the content of the list item
+// started by line begins: after its marker and the spaces that follow, or one
+// space after the marker when the item starts with indented code or is empty.
+func listContent(line string) int {
+	marker := mdListMarker.FindString(line)
+	col := indentation(marker) + len(strings.TrimLeft(marker, " \t"))
+	after := line[len(marker):]
+	if spaces := indentation(after); spaces >= 1 && spaces <= 4 && strings.TrimSpace(after) != "" {
+		return col + spaces
+	}
+	return col + 1
+}
+
+// frontMatter copies YAML or TOML front matter, translating the values of a
+// few well-known keys, and returns the index of the first line after it.
+func (w *mdWriter) frontMatter(lines []mdLine) int {
+	if len(lines) == 0 || (lines[0].text != "---" && lines[0].text != "+++") {
+		return 0
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if lines[i].text == lines[0].text {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return 0
+	}
+	for i := 0; i <= end; i++ {
+		l := lines[i]
+		m := mdFrontMatter.FindStringSubmatch(l.text)
+		if m == nil || i == 0 || i == end || strings.ContainsAny(m[2][:min(1, len(m[2]))], "|>[{&*!") {
+			w.verbatim(l.text + l.eol)
+			continue
+		}
+		value, quote := m[2], ""
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value, quote = value[1:len(value)-1], value[:1]
+		}
+		w.verbatim(m[1] + quote)
+		lead, trail := surroundingSpace(value)
+		w.verbatim(lead)
+		if strings.TrimSpace(value) != "" {
+			run := &mdRun{parts: []part{{kind: partText, text: strings.TrimSpace(value)}}}
+			w.pieces = append(w.pieces, mdPiece{run: run})
+			w.runs = append(w.runs, run)
+		}
+		w.verbatim(trail + quote + m[3] + l.eol)
+	}
+	return end + 1
+}
+
+// fenced copies a fenced code block starting at lines[i].
+func (w *mdWriter) fenced(lines []mdLine, i int) int {
+	open := mdFence.FindStringSubmatch(strings.TrimLeft(lines[i].text[len(mdBlockquote.FindString(lines[i].text)):], " \t"))[1]
+	w.verbatim(lines[i].text + lines[i].eol)
+	for i++; i < len(lines); i++ {
+		l := lines[i]
+		w.verbatim(l.text + l.eol)
+		rest := strings.TrimSpace(l.text[len(mdBlockquote.FindString(l.text)):])
+		if strings.HasPrefix(rest, open) && strings.Trim(rest, open[:1]) == "" {
+			return i + 1
+		}
+	}
+	return i
+}
+
+// htmlBlock copies an HTML block starting at lines[i].
+func (w *mdWriter) htmlBlock(lines []mdLine, i int) int {
+	end := htmlBlockEnd(lines[i].text[len(mdBlockquote.FindString(lines[i].text)):])
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		if end == "\n" && strings.TrimSpace(l.text) == "" {
+			return i
+		}
+		w.verbatim(l.text + l.eol)
+		if end != "\n" && strings.Contains(strings.ToLower(l.text), end) {
+			return i + 1
+		}
+	}
+	return i
+}
+
+// htmlBlockEnd returns what ends the HTML block started by line, "\n" for a
+// blank line, or "" when line does not start an HTML block.
+func htmlBlockEnd(line string) string {
+	m := mdHTMLBlock.FindStringSubmatch(line)
+	switch {
+	case m == nil:
+		return ""
+	case m[1] != "":
+		return "</" + strings.ToLower(m[1]) + ">"
+	case m[2] != "":
+		return "-->"
+	case m[3] != "":
+		return "?>"
+	case m[4] != "":
+		return ">"
+	case m[5] != "":
+		return "]]>"
+	}
+	return "\n"
+}
+
+// table handles a table whose header row is lines[i].
+func (w *mdWriter) table(lines []mdLine, i int) int {
+	w.row(lines[i])
+	w.verbatim(lines[i+1].text + lines[i+1].eol)
+	for i += 2; i < len(lines) && strings.TrimSpace(lines[i].text) != "" && strings.Contains(lines[i].text, "|"); i++ {
+		w.row(lines[i])
+	}
+	return i
+}
+
+// row translates the cells of a table row, leaving the pipes in place.
+func (w *mdWriter) row(l mdLine) {
+	quote := mdBlockquote.FindString(l.text)
+	w.verbatim(quote)
+	rest := l.text[len(quote):]
+	start := 0
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			i++
+		case '`':
+			n := runLength(rest[i:], '`')
+			if end := strings.Index(rest[i+n:], rest[i:i+n]); end >= 0 {
+				i += n + end + n - 1
+			} else {
+				i += n - 1
+			}
+		case '|':
+			w.text(rest[start:i])
+			w.verbatim("|")
+			start = i + 1
+		}
+	}
+	w.text(rest[start:])
+	w.verbatim(l.eol)
+}
+
+// paragraph handles the paragraph, list item or heading text starting at
+// lines[i]. Its lines are joined, except at hard line breaks.
+func (w *mdWriter) paragraph(lines []mdLine, i int) int {
+	var text []string
+	for first := true; i < len(lines); i, first = i+1, false {
+		l := lines[i]
+		quote := mdBlockquote.FindString(l.text)
+		rest := l.text[len(quote):]
+		if !first && !continuesParagraph(rest) {
+			break
+		}
+		prefix := quote
+		if first {
+			prefix += mdListItem.FindString(rest)
+		}
+		if len(text) == 0 {
+			content := strings.TrimLeft(l.text[len(prefix):], " \t")
+			w.verbatim(l.text[:len(l.text)-len(content)])
+		}
+		content := strings.TrimSpace(l.text[len(prefix):])
+		if brk := hardBreak(l.text); brk != "" {
+			text = append(text, strings.TrimSpace(strings.TrimSuffix(content, "\\")))
+			w.text(strings.Join(text, " "))
+			w.verbatim(brk + l.eol)
+			text = nil
+			continue
+		}
+		text = append(text, content)
+		if i+1 == len(lines) || !continuesParagraph(lines[i+1].text[len(mdBlockquote.FindString(lines[i+1].text)):]) {
+			w.text(strings.Join(text, " "))
+			w.verbatim(l.eol)
+			text = nil
+		}
+	}
+	return i
+}
+
+// continuesParagraph reports whether line, without its block quote markers,
+// continues the paragraph above it.
+func continuesParagraph(line string) bool {
+	if strings.TrimSpace(line) == "" || mdListItem.MatchString(line) || mdThematic.MatchString(line) ||
+		mdHeading.MatchString(line) || mdFence.MatchString(strings.TrimLeft(line, " \t")) {
+		return false
+	}
+	end := htmlBlockEnd(line)
+	return end == "" || (end == "\n" && mdHTMLBlock.FindStringSubmatch(line)[6] == "")
+}
+
+// hardBreak returns the hard line break ending line, if any.
+func hardBreak(line string) string {
+	if strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\") {
+		return "\\"
+	}
+	trimmed := strings.TrimRight(line, " ")
+	if len(line)-len(trimmed) >= 2 && trimmed != "" {
+		return line[len(trimmed):]
+	}
+	return ""
+}
+
+func indentation(s string) int {
+	n := 0
+	for _, r := range s {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4 - n%4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func runLength(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// mdInline splits inline Markdown into the parts of a run.
+type mdInline struct {
+	run  *mdRun
+	refs map[string]bool
+	buf  strings.Builder
+}
+
+func (in *mdInline) flush() {
+	if in.buf.Len() > 0 {
+		in.run.parts = append(in.run.parts, part{kind: partText, text: in.buf.String()})
+		in.buf.Reset()
+	}
+}
+
+func (in *mdInline) atom(s string) {
+	in.flush()
+	in.run.parts = append(in.run.parts, part{kind: partAtom, id: len(in.run.marks)})
+	in.run.marks = append(in.run.marks, mdMark{open: s})
+}
+
+// wrap adds the inline Markdown s between the markup open and close.
+func (in *mdInline) wrap(open, s, close string) {
+	in.flush()
+	id := len(in.run.marks)
+	in.run.marks = append(in.run.marks, mdMark{open, close})
+	in.run.parts = append(in.run.parts, part{kind: partOpen, id: id})
+	in.parse(s)
+	in.flush()
+	in.run.parts = append(in.run.parts, part{kind: partClose, id: id})
+}
+
+func (in *mdInline) parse(s string) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && unicode.IsPunct(rune(s[i+1])) || c == '\\' && i+1 < len(s) && unicode.IsSymbol(rune(s[i+1])):
+			in.atom(s[i : i+2])
+			i += 2
+		case c == '`':
+			n := runLength(s[i:], '`')
+			end := closingBackticks(s[i+n:], n)
+			if end < 0 {
+				in.buf.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+			in.atom(s[i : i+n+end+n])
+			i += n + end + n
+		case c == '<' && mdInlineHTML.MatchString(s[i:]):
+			m := mdInlineHTML.FindString(s[i:])
+			in.atom(m)
+			i += len(m)
+		case c == '&' && mdEntity.MatchString(s[i:]):
+			m := mdEntity.FindString(s[i:])
+			in.atom(m)
+			i += len(m)
+		case c == '!' && strings.HasPrefix(s[i:], "!["):
+			n := in.link(s[i+1:], "!")
+			if n == 0 {
+				in.buf.WriteByte(c)
+				i++
+				continue
+			}
+			i += 1 + n
+		case c == '[':
+			n := in.link(s[i:], "")
+			if n == 0 {
+				in.buf.WriteByte(c)
+				i++
+				continue
+			}
+			i += n
+		case c == '*' || c == '_' || c == '~':
+			i += in.emphasis(s, i)
+		case (i == 0 || strings.ContainsRune(" \t(", rune(s[i-1]))) && mdBareURL.MatchString(s[i:]):
+			m := mdBareURL.FindString(s[i:])
+			in.atom(m)
+			i += len(m)
+		default:
+			in.buf.WriteByte(c)
+			i++
+		}
+	}
+}
+
+// link adds the link or image starting at s, which begins with "[", and
+// returns its length, or 0 when s does not start a link.
+func (in *mdInline) link(s, bang string) int {
+	end := closingBracket(s, '[', ']')
+	if end < 0 {
+		return 0
+	}
+	label := s[1:end]
+	rest := s[end+1:]
+	switch {
+	case strings.HasPrefix(label, "^"):
+		// Footnote reference.
+		in.atom(bang + s[:end+1])
+		return end + 1
+	case strings.HasPrefix(rest, "("):
+		if close := closingBracket(rest, '(', ')'); close >= 0 {
+			in.wrap(bang+"[", label, s[end:end+2+close])
+			return end + 2 + close
+		}
+	case strings.HasPrefix(rest, "[]"):
+		// Collapsed reference: the label is the reference.
+		in.atom(bang + s[:end+3])
+		return end + 3
+	case strings.HasPrefix(rest, "["):
+		if close := closingBracket(rest, '[', ']'); close >= 0 {
+			in.wrap(bang+"[", label, s[end:end+2+close])
+			return end + 2 + close
+		}
+	case in.refs[normalizeLabel(label)]:
+		// Shortcut reference: the label is the reference.
+		in.atom(bang + s[:end+1])
+		return end + 1
+	}
+	return 0
+}
+
+// emphasis adds the emphasis starting at s[i] and returns its length, or
+// copies the delimiter run as text when it is not closed.
+func (in *mdInline) emphasis(s string, i int) int {
+	c := s[i]
+	n := runLength(s[i:], c)
+	k := min(n, 2)
+	if c == '~' && n != 2 {
+		k = n
+	}
+	delim := s[i : i+k]
+	next := i + k
+	opens := next < len(s) && !unicode.IsSpace(rune(s[next])) && (c != '_' || i == 0 || !isWordByte(s[i-1]))
+	if c == '~' && k != 2 {
+		opens = false
+	}
+	if opens {
+		for j := next + 1; j+k <= len(s); j++ {
+			switch s[j] {
+			case '\\':
+				j++
+				continue
+			case '`':
+				m := runLength(s[j:], '`')
+				if end := closingBackticks(s[j+m:], m); end >= 0 {
+					j += m + end + m - 1
+				} else {
+					j += m - 1
+				}
+				continue
+			}
+			if s[j:j+k] != delim || unicode.IsSpace(rune(s[j-1])) {
+				continue
+			}
+			if k == 1 && j+1 < len(s) && s[j+1] == c {
+				j++
+				continue
+			}
+			if c == '_' && j+k < len(s) && isWordByte(s[j+k]) {
+				continue
+			}
+			in.wrap(delim, s[next:j], delim)
+			return j + k - i
+		}
+	}
+	in.buf.WriteString(s[i : i+n])
+	return n
+}
+
+// closingBackticks returns the index in s of the backtick run of length n
+// closing a code span, or -1.
+func closingBackticks(s string, n int) int {
+	for j := 0; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s[j:], '`')
+		if m == n {
+			return j
+		}
+		j += m
+	}
+	return -1
+}
+
+// closingBracket returns the index of the bracket closing s[0], skipping
+// escapes, code spans and nested brackets, or -1.
+func closingBracket(s string, open, close byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			n := runLength(s[i:], '`')
+			if end := closingBackticks(s[i+n:], n); end >= 0 {
+				i += n + end + n - 1
+			} else {
+				i += n - 1
+			}
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isWordByte(b byte) bool {
+	return b >= 0x80 || b == '_' || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b))
+}
@@ -0,0 +1,153 @@
+package translator
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTranslateMarkdown(t *testing.T) {
+	var hits atomic.Int32
+	trans := newTestTranslator(t, upperHandler(&hits, false))
+
+	in := "---\n" +
+		"title: \"Getting started\"\n" +
+		"slug: getting-started\n" +
+		"---\n" +
+		"# Getting started #\n" +
+		"\n" +
+		"Install the **tool** with `go install`, then read\n" +
+		"the [guide](https://example.com/guide \"Guide\") or see https://example.com.\n" +
+		"A line with a hard break  \n" +
+		"and snake_case names.\n" +
+		"\n" +
+		"- First *item*\n" +
+		"- [x] Done item with [a reference][ref] and [ref]\n" +
+		"  1. Nested ![an image](img.png)\n" +
+		"\n" +
+		"> Quoted text\n" +
+		"\n" +
+		"```go\n" +
+		"fmt.Println(\"hello\")\n" +
+		"```\n" +
+		"\n" +
+		"    indented code\n" +
+		"\n" +
+		"| Name | Value `a|b` |\n" +
+		"|------|:-----:|\n" +
+		"| one  | two   |\n" +
+		"\n" +
+		"<div class=\"note\">\n" +
+		"html stays\n" +
+		"</div>\n" +
+		"\n" +
+		"Footnote[^1] and &copy; entity.\n" +
+		"\n" +
+		"[^1]: The footnote.\n" +
+		"[ref]: https://example.com/ref\n" +
+		"***\n" +
+		"Setext heading\n" +
+		"==============\n"
+
+	want := "---\n" +
+		"title: \"GETTING STARTED\"\n" +
+		"slug: getting-started\n" +
+		"---\n" +
+		"# GETTING STARTED #\n" +
+		"\n" +
+		"INSTALL THE **TOOL** WITH `go install`, THEN READ THE [GUIDE](https://example.com/guide \"Guide\") OR SEE https://example.com. A LINE WITH A HARD BREAK  \n" +
+		"AND SNAKE_CASE NAMES.\n" +
+		"\n" +
+		"- FIRST *ITEM*\n" +
+		"- [x] DONE ITEM WITH [A REFERENCE][ref] AND [ref]\n" +
+		"  1. NESTED ![AN IMAGE](img.png)\n" +
+		"\n" +
+		"> QUOTED TEXT\n" +
+		"\n" +
+		"```go\n" +
+		"fmt.Println(\"hello\")\n" +
+		"```\n" +
+		"\n" +
+		"    indented code\n" +
+		"\n" +
+		"| NAME | VALUE `a|b` |\n" +
+		"|------|:-----:|\n" +
+		"| ONE  | TWO   |\n" +
+		"\n" +
+		"<div class=\"note\">\n" +
+		"html stays\n" +
+		"</div>\n" +
+		"\n" +
+		"FOOTNOTE[^1] AND &copy; ENTITY.\n" +
+		"\n" +
+		"[^1]: THE FOOTNOTE.\n" +
+		"[ref]: https://example.com/ref\n" +
+		"***\n" +
+		"SETEXT HEADING\n" +
+		"==============\n"
+
+	got, err := trans.TranslateMarkdown(context.Background(), in, "en", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTranslateMarkdown_Lists(t *testing.T) {
+	var hits atomic.Int32
+	trans := newTestTranslator(t, upperHandler(&hits, false))
+
+	in := "- item\n" +
+		"\n" +
+		"      code in item\n" +
+		"\n" +
+		"  more text\n" +
+		"lazy continuation\n" +
+		"- first line\n" +
+		"        continued\n" +
+		"  - nested\n" +
+		"\n" +
+		"        nested code\n" +
+		"\n" +
+		"1. step\n" +
+		"\n" +
+		"       step code\n" +
+		"   ```\n" +
+		"   fenced code\n" +
+		"   ```\n" +
+		"\n" +
+		"after the list\n" +
+		"\n" +
+		"    top-level code\n"
+
+	want := "- ITEM\n" +
+		"\n" +
+		"      code in item\n" +
+		"\n" +
+		"  MORE TEXT LAZY CONTINUATION\n" +
+		"- FIRST LINE CONTINUED\n" +
+		"  - NESTED\n" +
+		"\n" +
+		"        nested code\n" +
+		"\n" +
+		"1. STEP\n" +
+		"\n" +
+		"       step code\n" +
+		"   ```\n" +
+		"   fenced code\n" +
+		"   ```\n" +
+		"\n" +
+		"AFTER THE LIST\n" +
+		"\n" +
+		"    top-level code\n"
+
+	got, err := trans.TranslateMarkdown(context.Background(), in, "en", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,239 @@
+package translator
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Documents are translated one inline run at a time: the text of a paragraph,
+// a heading or a table cell together with the formatting inside it, so that
+// sentences crossing a link or an emphasis are translated whole. The
+// formatting is replaced by numbered markers that the service leaves alone,
+// "[[1]]bold[[/1]]" around content and "[[2/]]" for content kept verbatim,
+// and put back after translation.
+
+type partKind int
+
+const (
+	partText  partKind = iota // text to translate
+	partOpen                  // start of formatting around translated content
+	partClose                 // end of the formatting opened by the partOpen with the same id
+	partAtom                  // content kept verbatim
+)
+
+// part is a piece of an inline run. id refers to the formatting of partOpen,
+// partClose and partAtom parts in the caller's own table.
+type part struct {
+	kind partKind
+	id   int
+	text string
+}
+
+// marker matches the markers of an encoded run, allowing for the spaces the
+// service sometimes inserts.
+var marker = regexp.MustCompile(`\[\[\s*(/?)\s*(\d+)\s*(/?)\s*\]\]`)
+
+// encodeRun turns parts into the text sent for translation.
+func encodeRun(parts []part) string {
+	var b strings.Builder
+	for _, p := range parts {
+		switch p.kind {
+		case partText:
+			b.WriteString(p.text)
+		case partOpen:
+			b.WriteString("[[" + strconv.Itoa(p.id) + "]]")
+		case partClose:
+			b.WriteString("[[/" + strconv.Itoa(p.id) + "]]")
+		case partAtom:
+			b.WriteString("[[" + strconv.Itoa(p.id) + "/]]")
+		}
+	}
+	return b.String()
+}
+
+// decodeRun parses the translation of the run encoded from parts. It reports
+// false unless every marker of parts comes back exactly once and properly
+// nested.
+func decodeRun(translated string, parts []part) ([]part, bool) {
+	want := map[part]bool{}
+	for _, p := range parts {
+		if p.kind != partText {
+			want[part{kind: p.kind, id: p.id}] = true
+		}
+	}
+
+	var decoded []part
+	var open []int
+	text := func(s string) {
+		if s != "" {
+			decoded = append(decoded, part{kind: partText, text: s})
+		}
+	}
+	last := 0
+	for _, m := range marker.FindAllStringSubmatchIndex(translated, -1) {
+		text(translated[last:m[0]])
+		last = m[1]
+		id, _ := strconv.Atoi(translated[m[4]:m[5]])
+		p := part{kind: partOpen, id: id}
+		switch {
+		case m[3] > m[2] && m[7] > m[6]:
+			return nil, false
+		case m[3] > m[2]:
+			p.kind = partClose
+			if len(open) == 0 || open[len(open)-1] != id {
+				return nil, false
+			}
+			open = open[:len(open)-1]
+		case m[7] > m[6]:
+			p.kind = partAtom
+		default:
+			open = append(open, id)
+		}
+		if !want[p] {
+			return nil, false
+		}
+		delete(want, p)
+		decoded = append(decoded, p)
+	}
+	text(translated[last:])
+	if len(want) > 0 || len(open) > 0 {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// translateRuns translates runs of parts, returning the parts of each
+// translated run. Whitespace around a run is kept as is. Runs whose markers do
+// not survive translation have their texts translated one by one instead,
+// keeping the original formatting.
+func (a *Translator) translateRuns(ctx context.Context, runs [][]part, src, dest string) ([][]part, error) {
+	texts := make([]string, len(runs))
+	for i, parts := range runs {
+		texts[i] = encodeRun(parts)
+	}
+	translated, err := a.TranslateTexts(ctx, texts, src, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	// Runs whose markers did not survive are translated again text by text.
+	// They are tracked explicitly, as a run may legitimately decode to nil.
+	out := make([][]part, len(runs))
+	retry := make([]bool, len(runs))
+	var fallback []string
+	for i, parts := range runs {
+		if strings.TrimSpace(texts[i]) == "" {
+			out[i] = parts
+			continue
+		}
+		lead, trail := surroundingSpace(texts[i])
+		if decoded, ok := decodeRun(lead+strings.TrimSpace(translated[i])+trail, parts); ok {
+			out[i] = decoded
+			continue
+		}
+		retry[i] = true
+		for _, p := range parts {
+			if p.kind == partText {
+				fallback = append(fallback, p.text)
+			}
+		}
+	}
+	if fallback == nil {
+		for i, parts := range runs {
+			if retry[i] {
+				out[i] = parts
+			}
+		}
+		return out, nil
+	}
+
+	translated, err = a.TranslateTexts(ctx, fallback, src, dest)
+	if err != nil {
+		return nil, err
+	}
+	for i, parts := range runs {
+		if !retry[i] {
+			continue
+		}
+		out[i] = make([]part, len(parts))
+		for j, p := range parts {
+			if p.kind == partText {
+				lead, trail := surroundingSpace(p.text)
+				if strings.TrimSpace(p.text) != "" {
+					p.text = lead + strings.TrimSpace(translated[0]) + trail
+				}
+				translated = translated[1:]
+			}
+			out[i][j] = p
+		}
+	}
+	return out, nil
+}
+
+// surroundingSpace returns the leading and trailing whitespace of s.
+func surroundingSpace(s string) (string, string) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s, ""
+	}
+	start := strings.Index(s, trimmed)
+	return s[:start], s[start+len(trimmed):]
+}
+
+// ProtectedTranslator is what the packages translating file formats, such as
+// subtitle and gettext, need of a Translator, so that they can be given a
+// fake one in tests.
+type ProtectedTranslator interface {
+	TranslateProtected(ctx context.Context, texts []string, protect *regexp.Regexp, src, dest string) ([]string, error)
+}
+
+var _ ProtectedTranslator = (*Translator)(nil)
+
+// TranslateProtected translates texts like TranslateTexts, keeping the
+// substrings matched by protect unchanged: markup, placeholders or format
+// verbs that the service must not translate. They are moved along with the
+// words around them; when that fails for a text, the pieces between them are
+// translated one by one instead.
+//
+// Example Usage:
+//
+//	placeholders := regexp.MustCompile(`\{\{\w+\}\}`)
+//	translated, err := translator.TranslateProtected(ctx, []string{"Hello {{name}}"}, placeholders, "en", "es")
+func (a *Translator) TranslateProtected(ctx context.Context, texts []string, protect *regexp.Regexp, src, dest string) ([]string, error) {
+	runs := make([][]part, len(texts))
+	atoms := make([][]string, len(texts))
+	for i, text := range texts {
+		last := 0
+		for _, m := range protect.FindAllStringIndex(text, -1) {
+			if m[0] > last {
+				runs[i] = append(runs[i], part{kind: partText, text: text[last:m[0]]})
+			}
+			runs[i] = append(runs[i], part{kind: partAtom, id: len(atoms[i])})
+			atoms[i] = append(atoms[i], text[m[0]:m[1]])
+			last = m[1]
+		}
+		if last < len(text) {
+			runs[i] = append(runs[i], part{kind: partText, text: text[last:]})
+		}
+	}
+
+	runs, err := a.translateRuns(ctx, runs, src, dest)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(texts))
+	for i, parts := range runs {
+		var b strings.Builder
+		for _, p := range parts {
+			if p.kind == partAtom {
+				b.WriteString(atoms[i][p.id])
+			} else {
+				b.WriteString(p.text)
+			}
+		}
+		out[i] = b.String()
+	}
+	return out, nil
+}
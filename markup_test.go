@@ -0,0 +1,87 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDecodeRun(t *testing.T) {
+	parts := []part{{kind: partText, text: "a "}, {kind: partOpen, id: 0}, {kind: partText, text: "b"}, {kind: partClose, id: 0}, {kind: partAtom, id: 1}}
+	tests := []struct {
+		translated string
+		ok         bool
+	}{
+		{"A [[0]]B[[/0]][[1/]]", true},
+		{"[[1/]] A [[ 0 ]]B[[ /0 ]]", true},
+		{"A B[[1/]]", false},
+		{"A [[0]]B[[/0]][[1/]][[1/]]", false},
+		{"A [[/0]]B[[0]][[1/]]", false},
+		{"A [[0]]B[[/0]][[1/]][[2/]]", false},
+	}
+	for _, tt := range tests {
+		if _, ok := decodeRun(tt.translated, parts); ok != tt.ok {
+			t.Errorf("decodeRun(%q) = %v, want %v", tt.translated, ok, tt.ok)
+		}
+	}
+}
+
+func TestTranslateProtected(t *testing.T) {
+	var hits atomic.Int32
+	trans := newTestTranslator(t, upperHandler(&hits, false))
+	placeholders := regexp.MustCompile(`\{\{\w+\}\}|%s`)
+
+	got, err := trans.TranslateProtected(context.Background(), []string{"Hello {{name}}, you have %s", "", "{{count}}"}, placeholders, "en", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"HELLO {{name}}, YOU HAVE %s", "", "{{count}}"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("text %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTranslateRuns_EmptyAndFallback(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		q := r.URL.Query().Get("q")
+		switch {
+		case q == "gone":
+			q = ""
+		case strings.Contains(q, "[["):
+			// Lose the markers, so that the run is translated text by text.
+			q = marker.ReplaceAllString(q, "")
+		}
+		json.NewEncoder(w).Encode(sentences{Sentences: []sentence{{Trans: strings.ToUpper(q), Orig: q}}})
+	}))
+
+	runs := [][]part{
+		{{kind: partText, text: "gone"}},
+		{{kind: partText, text: "a "}, {kind: partOpen, id: 0}, {kind: partText, text: "b"}, {kind: partClose, id: 0}},
+	}
+	out, err := trans.translateRuns(context.Background(), runs, "en", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out[0]) != 0 {
+		t.Errorf("run 0: got %+v, want no parts", out[0])
+	}
+	want := []part{{kind: partText, text: "A "}, {kind: partOpen, id: 0}, {kind: partText, text: "B"}, {kind: partClose, id: 0}}
+	if len(out[1]) != len(want) {
+		t.Fatalf("run 1: got %+v, want %+v", out[1], want)
+	}
+	for i := range want {
+		if out[1][i] != want[i] {
+			t.Errorf("run 1 part %d: got %+v, want %+v", i, out[1][i], want[i])
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// probeConcurrency is the number of hosts probed at the same time.
+	probeConcurrency = 8
+	// probeText is translated from English to Spanish to check the translate endpoint.
+	probeText = "hello"
+	// probeDecay is the weight of the latest probe in the latency and error rate of a host.
+	probeDecay = 0.3
+	// probeSample is the number of hosts probed in each round of background probing.
+	probeSample = 16
+	// probeKeep is the number of best ranked hosts probed in every round.
+	probeKeep = 3
+)
+
+// ProbeResult is the outcome of probing a service host.
+type ProbeResult struct {
+	Host      string
+	Homepage  bool          // the homepage was served
+	TKK       bool          // the homepage contains a TKK; the translate endpoint works without one
+	Translate bool          // the translate endpoint returned a valid translation
+	Latency   time.Duration // time taken by the whole probe
+	Err       error         // first error encountered, nil if the host is healthy
+}
+
+// Healthy reports whether the host can serve translations.
+func (r ProbeResult) Healthy() bool {
+	return r.Homepage && r.Translate
+}
+
+// HostScore is the ranking of a service host over the probes made by a Translator.
+type HostScore struct {
+	Host      string
+	Latency   time.Duration // moving average of the latency of successful probes
+	ErrorRate float64       // moving average of failed probes, from 0 to 1
+	Probes    int           // number of probes made
+}
+
+// ProbeHosts checks every host the way a call uses it: it fetches the homepage,
+// looks for the TKK and translates a short text. The results are sorted from
+// the best host to the worst: healthy hosts first, by latency. If hosts is
+// empty, the ServiceUrls of the config, or else the default service URLs, are
+// probed. The config is otherwise used as by New, e.g. for proxies and TLS.
+func ProbeHosts(ctx context.Context, hosts []string, config ...Config) []ProbeResult {
+	var c Config
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if len(hosts) == 0 {
+		hosts = c.ServiceUrls
+	}
+	if len(hosts) == 0 {
+		hosts = defaultServiceUrls
+	}
+	c.ProbeInterval = 0
+	a := New(c)
+
+	results := a.probeAll(ctx, hosts)
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Healthy() != results[j].Healthy() {
+			return results[i].Healthy()
+		}
+		return results[i].Latency < results[j].Latency
+	})
+	return results
+}
+
+// probeAll probes hosts with bounded concurrency, returning results in the order of hosts.
+func (a *Translator) probeAll(ctx context.Context, hosts []string) []ProbeResult {
+	results := make([]ProbeResult, len(hosts))
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, host string) {
+			defer wg.Done()
+			results[i] = a.probe(ctx, host)
+			<-sem
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
+
+// probe checks a single host.
+func (a *Translator) probe(ctx context.Context, host string) (result ProbeResult) {
+	result.Host = host
+	start := time.Now()
+	defer func() { result.Latency = time.Since(start) }()
+
+	ta := a.newToken(host)
+	req, err := http.NewRequestWithContext(ctx, "GET", ta.host, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	body, err := a.up.send(a.client, req, upstreamCall{op: OpTokenRefresh, probe: true})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Homepage = true
+	if rawTkk := ReTkk.FindStringSubmatch(string(body)); len(rawTkk) > 0 {
+		result.TKK = true
+		ta.tkk = rawTkk[1]
+	}
+
+	req, err = newTranslateRequest(ctx, host, ta.acquire(probeText), probeText, "en", "es")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	body, err = a.up.send(a.client, req, upstreamCall{op: OpTranslate, src: "en", dest: "es", probe: true})
+	if err == nil {
+		var s sentences
+		if err = json.Unmarshal(body, &s); err != nil {
+			err = unexpectedResponse(host, err)
+		} else if len(s.Sentences) == 0 {
+			err = unexpectedResponse(host, errors.New("no sentences"))
+		}
+	}
+	result.Translate = err == nil
+	result.Err = err
+	return result
+}
+
+// hostRanker keeps the scores of the hosts probed by a Translator.
+type hostRanker struct {
+	mu     sync.Mutex
+	scores map[string]*HostScore
+}
+
+func (r *hostRanker) update(results []ProbeResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, result := range results {
+		s := r.scores[result.Host]
+		if s == nil {
+			s = &HostScore{Host: result.Host}
+			r.scores[result.Host] = s
+		}
+		failed := 1.0
+		if result.Healthy() {
+			failed = 0
+			if s.Latency == 0 {
+				s.Latency = result.Latency
+			} else {
+				s.Latency += time.Duration(probeDecay * float64(result.Latency-s.Latency))
+			}
+		}
+		if s.Probes == 0 {
+			s.ErrorRate = failed
+		} else {
+			s.ErrorRate += probeDecay * (failed - s.ErrorRate)
+		}
+		s.Probes++
+	}
+}
+
+// ranking returns the scores from the best host to the worst: by error rate,
+// then by latency.
+func (r *hostRanker) ranking() []HostScore {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scores := make([]HostScore, 0, len(r.scores))
+	for _, s := range r.scores {
+		scores = append(scores, *s)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].ErrorRate != scores[j].ErrorRate {
+			return scores[i].ErrorRate < scores[j].ErrorRate
+		}
+		if scores[i].Latency != scores[j].Latency {
+			return scores[i].Latency < scores[j].Latency
+		}
+		return scores[i].Host < scores[j].Host
+	})
+	return scores
+}
+
+// HostScores returns the ranking of the service hosts built by background
+// probing, from the best host to the worst. It is empty unless
+// Config.ProbeInterval is set.
+func (a *Translator) HostScores() []HostScore {
+	return a.ranker.ranking()
+}
+
+// probeLoop probes a sample of hosts every interval until ctx is done, moving
+// the Translator to the best ranked host. Each round probes the current host,
+// the best ranked ones and the next of the others in turn.
+func (a *Translator) probeLoop(ctx context.Context, hosts []string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	next := 0
+	for {
+		keep := []string{a.GetHost()}
+		for i, s := range a.ranker.ranking() {
+			if i == probeKeep || s.ErrorRate == 1 {
+				break
+			}
+			keep = append(keep, s.Host)
+		}
+		results := a.probeAll(ctx, sampleHosts(hosts, keep, &next, probeSample))
+		if ctx.Err() != nil {
+			// The probes cut off by Close are not host failures.
+			return
+		}
+		a.ranker.update(results)
+		ranking := a.ranker.ranking()
+		if len(ranking) > 0 && ranking[0].ErrorRate < 1 && ranking[0].Host != a.GetHost() {
+			a.up.logger.Info("selected best probed host", slog.String("host", ranking[0].Host),
+				slog.Duration("latency", ranking[0].Latency), slog.Float64("error_rate", ranking[0].ErrorRate))
+			a.SetHost(ranking[0].Host)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sampleHosts returns at most n hosts: those of keep, then the others of hosts
+// in turn from *next, which is advanced so that successive samples cover every
+// host.
+func sampleHosts(hosts, keep []string, next *int, n int) []string {
+	if len(hosts) <= n {
+		return hosts
+	}
+	seen := make(map[string]bool, n)
+	var sample []string
+	for _, host := range keep {
+		if len(sample) < n && !seen[host] {
+			seen[host] = true
+			sample = append(sample, host)
+		}
+	}
+	for i := 0; i < len(hosts) && len(sample) < n; i++ {
+		host := hosts[*next%len(hosts)]
+		*next++
+		if !seen[host] {
+			seen[host] = true
+			sample = append(sample, host)
+		}
+	}
+	return sample
+}
+
+// Close stops background probing, waiting for a round in progress to be
+// abandoned. The Translator remains usable.
+func (a *Translator) Close() error {
+	if a.stopProbing != nil {
+		a.stopProbing()
+		<-a.probeDone
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package translator
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbeHosts(t *testing.T) {
+	serve := func(delay time.Duration, translate bool) string {
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			switch {
+			case r.URL.Path != "/translate_a/single":
+				w.Write([]byte("<html>tkk:'448487.932609646'</html>"))
+			case translate:
+				w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+			default:
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			}
+		}))
+		t.Cleanup(srv.Close)
+		return srv.Listener.Addr().String()
+	}
+	slow := serve(50*time.Millisecond, true)
+	broken := serve(0, false)
+	fast := serve(0, true)
+
+	// All httptest servers share one certificate.
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	srv.Close()
+
+	results := ProbeHosts(context.Background(), []string{slow, broken, fast}, Config{RootCAs: roots})
+	if len(results) != 3 {
+		t.Fatalf("got %d results", len(results))
+	}
+	if results[0].Host != fast || results[1].Host != slow || results[2].Host != broken {
+		t.Fatalf("unexpected order %+v", results)
+	}
+	if !results[0].Healthy() || !results[0].TKK || results[2].Healthy() || !results[2].Homepage || results[2].Err == nil {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	trans := New(Config{ServiceUrls: []string{broken, slow, fast}, RootCAs: roots, ProbeInterval: time.Hour})
+	defer trans.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	// The initial host is random and may already be fast, so wait for the scores as well.
+	for (trans.GetHost() != fast || len(trans.HostScores()) == 0) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if trans.GetHost() != fast {
+		t.Fatalf("background probing selected %s, want %s", trans.GetHost(), fast)
+	}
+	scores := trans.HostScores()
+	if len(scores) != 3 || scores[0].Host != fast || scores[2].Host != broken || scores[2].ErrorRate != 1 {
+		t.Fatalf("unexpected scores %+v", scores)
+	}
+}
+
+func TestSampleHosts(t *testing.T) {
+	hosts := []string{"a", "b", "c", "d", "e"}
+	if got := sampleHosts(hosts, []string{"x"}, new(int), 5); len(got) != 5 {
+		t.Fatalf("small list sampled to %v", got)
+	}
+
+	next := 0
+	seen := map[string]int{}
+	for round := 0; round < 3; round++ {
+		got := sampleHosts(hosts, []string{"c", "a"}, &next, 3)
+		if len(got) != 3 || got[0] != "c" || got[1] != "a" {
+			t.Fatalf("round %d: got %v", round, got)
+		}
+		seen[got[2]]++
+	}
+	// The remaining slot rotates over the other hosts.
+	if seen["b"] != 1 || seen["d"] != 1 || seen["e"] != 1 {
+		t.Fatalf("rotating slot probed %v", seen)
+	}
+}
+
+func TestTranslator_CloseDuringProbe(t *testing.T) {
+	started := make(chan struct{}, 1)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	trans := New(Config{ServiceUrls: []string{srv.Listener.Addr().String()}, RootCAs: roots, ProbeInterval: time.Hour})
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("probe did not start")
+	}
+	trans.Close()
+	// Give a loop still running after Close the time to score the round.
+	time.Sleep(50 * time.Millisecond)
+	if scores := trans.HostScores(); len(scores) != 0 {
+		t.Fatalf("canceled probes were scored after Close: %+v", scores)
+	}
+}
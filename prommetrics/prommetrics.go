@@ -0,0 +1,119 @@
+// Package prommetrics exposes the upstream requests of a translator.Translator
+// as Prometheus metrics.
+//
+// A Collector is both a translator.Instrumentation and a prometheus.Collector:
+//
+//	collector := prommetrics.New()
+//	prometheus.MustRegister(collector)
+//	t := translator.New(translator.Config{Instrumentation: collector})
+//
+// The following metrics are exported, prefixed by the configured namespace:
+//
+//	upstream_requests_total{operation,host,src,dest,result}
+//	upstream_request_duration_seconds{operation,host,src,dest}
+//	upstream_response_bytes_total{operation,host}
+//	upstream_retries_total{operation,host}
+//
+// result is the status code of the response, or "captcha", "consent" or
+// "error" when the request was blocked or did not get a response.
+package prommetrics
+
+import (
+	"errors"
+	"strconv"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const defaultNamespace = "gtrans"
+
+// Config basic opts.
+type Config struct {
+	Namespace string    // metric name prefix, "gtrans" if empty
+	Buckets   []float64 // latency histogram buckets in seconds, prometheus.DefBuckets if empty
+}
+
+// Collector records translator.RequestInfo values as Prometheus metrics.
+type Collector struct {
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	responseBytes *prometheus.CounterVec
+	retries       *prometheus.CounterVec
+}
+
+// New creates a Collector. It still has to be registered with a prometheus.Registerer.
+func New(config ...Config) *Collector {
+	var c Config
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Namespace == "" {
+		c.Namespace = defaultNamespace
+	}
+	if len(c.Buckets) == 0 {
+		c.Buckets = prometheus.DefBuckets
+	}
+
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.Namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Upstream requests made to Google Translate, by result.",
+		}, []string{"operation", "host", "src", "dest", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: c.Namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of upstream requests made to Google Translate.",
+			Buckets:   c.Buckets,
+		}, []string{"operation", "host", "src", "dest"}),
+		responseBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.Namespace,
+			Name:      "upstream_response_bytes_total",
+			Help:      "Response body bytes read from Google Translate.",
+		}, []string{"operation", "host"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.Namespace,
+			Name:      "upstream_retries_total",
+			Help:      "Upstream requests that were retries of a failed attempt.",
+		}, []string{"operation", "host"}),
+	}
+}
+
+// ObserveRequest implements translator.Instrumentation.
+func (c *Collector) ObserveRequest(info translator.RequestInfo) {
+	op := string(info.Operation)
+	c.requests.WithLabelValues(op, info.Host, info.Src, info.Dest, result(info)).Inc()
+	c.duration.WithLabelValues(op, info.Host, info.Src, info.Dest).Observe(info.Latency.Seconds())
+	c.responseBytes.WithLabelValues(op, info.Host).Add(float64(info.ResponseBytes))
+	if info.Retry > 0 {
+		c.retries.WithLabelValues(op, info.Host).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.responseBytes.Describe(ch)
+	c.retries.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.responseBytes.Collect(ch)
+	c.retries.Collect(ch)
+}
+
+func result(info translator.RequestInfo) string {
+	var blocked *translator.BlockedError
+	switch {
+	case errors.As(info.Err, &blocked):
+		return string(blocked.Kind)
+	case info.StatusCode == 0:
+		return "error"
+	}
+	return strconv.Itoa(info.StatusCode)
+}
@@ -0,0 +1,54 @@
+package prommetrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := New(Config{Namespace: "test"})
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+
+	c.ObserveRequest(translator.RequestInfo{
+		Operation: translator.OpTranslate, Host: "translate.google.com", Src: "en", Dest: "es",
+		StatusCode: 200, Latency: 20 * time.Millisecond, ResponseBytes: 100,
+	})
+	c.ObserveRequest(translator.RequestInfo{
+		Operation: translator.OpTranslate, Host: "translate.google.com", Src: "en", Dest: "es",
+		StatusCode: 200, Latency: 30 * time.Millisecond, ResponseBytes: 50, Retry: 1,
+		Err: &translator.BlockedError{Kind: translator.BlockCaptcha, Host: "translate.google.com", StatusCode: 200},
+	})
+	c.ObserveRequest(translator.RequestInfo{
+		Operation: translator.OpTokenRefresh, Host: "translate.google.com", Err: errors.New("refused"),
+	})
+
+	expected := `
+# HELP test_upstream_requests_total Upstream requests made to Google Translate, by result.
+# TYPE test_upstream_requests_total counter
+test_upstream_requests_total{dest="",host="translate.google.com",operation="token_refresh",result="error",src=""} 1
+test_upstream_requests_total{dest="es",host="translate.google.com",operation="translate",result="200",src="en"} 1
+test_upstream_requests_total{dest="es",host="translate.google.com",operation="translate",result="captcha",src="en"} 1
+# HELP test_upstream_response_bytes_total Response body bytes read from Google Translate.
+# TYPE test_upstream_response_bytes_total counter
+test_upstream_response_bytes_total{host="translate.google.com",operation="token_refresh"} 0
+test_upstream_response_bytes_total{host="translate.google.com",operation="translate"} 150
+# HELP test_upstream_retries_total Upstream requests that were retries of a failed attempt.
+# TYPE test_upstream_retries_total counter
+test_upstream_retries_total{host="translate.google.com",operation="translate"} 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"test_upstream_requests_total", "test_upstream_response_bytes_total", "test_upstream_retries_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(c, "test_upstream_request_duration_seconds"); n != 2 {
+		t.Fatalf("%d duration series, want 2", n)
+	}
+}
@@ -0,0 +1,233 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ProxyRotation selects which proxy of the pool a request goes through.
+type ProxyRotation int
+
+const (
+	// RotateRoundRobin uses the available proxies in turn.
+	RotateRoundRobin ProxyRotation = iota
+	// RotateRandom picks a random available proxy for every request.
+	RotateRandom
+	// RotateSticky keeps using the same proxy for a service host until it is evicted.
+	RotateSticky
+)
+
+const (
+	defaultProxyMaxFailures = 3
+	defaultProxyEvictFor    = 5 * time.Minute
+)
+
+// ProxyStatus describes the health of a proxy of the pool.
+type ProxyStatus struct {
+	URL          string    // proxy URL without credentials
+	Successes    int       // requests that got a response through the proxy
+	Failures     int       // requests that failed with a connection error or a captcha page
+	EvictedUntil time.Time // end of the current eviction, zero if the proxy is in rotation
+}
+
+// proxyPolicy holds the pool settings of a Config, kept to rebuild the pool in SetProxies.
+type proxyPolicy struct {
+	rotation    ProxyRotation
+	maxFailures int
+	evictFor    time.Duration
+}
+
+func newProxyPolicy(c Config) proxyPolicy {
+	p := proxyPolicy{rotation: c.ProxyRotation, maxFailures: c.ProxyMaxFailures, evictFor: c.ProxyEvictFor}
+	if p.maxFailures <= 0 {
+		p.maxFailures = defaultProxyMaxFailures
+	}
+	if p.evictFor <= 0 {
+		p.evictFor = defaultProxyEvictFor
+	}
+	return p
+}
+
+type proxyState struct {
+	url          *url.URL
+	successes    int
+	failures     int
+	consecutive  int // connection errors since the last success
+	evictedUntil time.Time
+}
+
+// proxyPool rotates requests over a set of proxies and evicts the ones that fail.
+// A nil *proxyPool sends requests directly.
+type proxyPool struct {
+	mu      sync.Mutex
+	proxies []*proxyState
+	policy  proxyPolicy
+	next    int
+	sticky  map[string]*proxyState // service host to proxy, for RotateSticky
+	logger  *slog.Logger
+}
+
+func newProxyPool(urls []*url.URL, policy proxyPolicy, logger *slog.Logger) *proxyPool {
+	if len(urls) == 0 {
+		return nil
+	}
+	p := &proxyPool{policy: policy, sticky: map[string]*proxyState{}, logger: logger}
+	for _, u := range urls {
+		p.proxies = append(p.proxies, &proxyState{url: u})
+	}
+	return p
+}
+
+// pick returns the proxy to send a request for host through, or nil to send it directly.
+func (p *proxyPool) pick(host string) (*proxyState, error) {
+	if p == nil {
+		return nil, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if s := p.sticky[host]; s != nil && !now.Before(s.evictedUntil) {
+		return s, nil
+	}
+	available := make([]*proxyState, 0, len(p.proxies))
+	for _, s := range p.proxies {
+		if !now.Before(s.evictedUntil) {
+			available = append(available, s)
+		}
+	}
+	if len(available) == 0 {
+		return nil, ErrNoProxy
+	}
+
+	var s *proxyState
+	switch p.policy.rotation {
+	case RotateRandom:
+		s = available[rand.Intn(len(available))]
+	default:
+		s = available[p.next%len(available)]
+		p.next++
+	}
+	if p.policy.rotation == RotateSticky {
+		p.sticky[host] = s
+	}
+	return s, nil
+}
+
+// report records the outcome of a request sent through s. Captcha pages evict
+// the proxy at once, failures to connect through it after policy.maxFailures
+// in a row. Other transport errors, such as a TLS failure of the service, say
+// nothing about the proxy and are not counted.
+func (p *proxyPool) report(ctx context.Context, s *proxyState, err error) {
+	if s == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	var opErr *net.OpError
+	var urlErr *url.Error
+	reason := ""
+
+	p.mu.Lock()
+	switch {
+	case errors.Is(err, ErrCaptcha):
+		s.failures++
+		reason = "captcha"
+	case errors.As(err, &opErr) && opErr.Op == "proxyconnect":
+		// Dialing the proxy, its CONNECT or SOCKS handshake failed.
+		s.failures++
+		s.consecutive++
+		if s.consecutive >= p.policy.maxFailures {
+			reason = "connection errors"
+		}
+	case errors.As(err, &urlErr):
+	default:
+		// Any response, even an error status, means the proxy works.
+		s.successes++
+		s.consecutive = 0
+	}
+	if reason != "" {
+		s.consecutive = 0
+		s.evictedUntil = time.Now().Add(p.policy.evictFor)
+	}
+	p.mu.Unlock()
+
+	if reason != "" {
+		p.logger.LogAttrs(ctx, slog.LevelWarn, "evicted proxy",
+			slog.String("proxy", redactedURL(s.url)), slog.String("reason", reason), slog.Duration("for", p.policy.evictFor))
+	}
+}
+
+func (p *proxyPool) status() []ProxyStatus {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	status := make([]ProxyStatus, len(p.proxies))
+	for i, s := range p.proxies {
+		status[i] = ProxyStatus{URL: redactedURL(s.url), Successes: s.successes, Failures: s.failures}
+		if now.Before(s.evictedUntil) {
+			status[i].EvictedUntil = s.evictedUntil
+		}
+	}
+	return status
+}
+
+// ValidateProxy reports whether proxy is a URL accepted by SetProxy and
+// Config.Proxies. The error never holds the credentials of the URL.
+func ValidateProxy(proxy string) error {
+	_, err := parseProxy(proxy)
+	return err
+}
+
+// parseProxy parses an http, https or socks5 proxy URL. Credentials may be given as user info.
+func parseProxy(proxy string) (*url.URL, error) {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		// The error of url.Parse quotes the URL, credentials included.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy %q", redactedURL(u))
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q has no host", redactedURL(u))
+	}
+	return u, nil
+}
+
+// parseProxies parses every proxy URL, stopping at the first invalid one.
+func parseProxies(proxies []string) ([]*url.URL, error) {
+	urls := make([]*url.URL, 0, len(proxies))
+	for _, proxy := range proxies {
+		u, err := parseProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
+}
+
+type proxyKey struct{}
+
+// proxyURL is the http.Transport Proxy function of the Translator. It returns
+// the proxy that upstream.send picked for the request.
+func proxyURL(req *http.Request) (*url.URL, error) {
+	u, _ := req.Context().Value(proxyKey{}).(*url.URL)
+	return u, nil
+}
@@ -0,0 +1,157 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// connectProxy is a minimal HTTP CONNECT proxy counting the tunnels it opens.
+func connectProxy(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var tunnels atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		tunnels.Add(1)
+		w.WriteHeader(http.StatusOK)
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &tunnels
+}
+
+func TestProxyPool_Rotation(t *testing.T) {
+	var urls []*url.URL
+	for _, p := range []string{"http://a:1", "https://b:2", "socks5://user:pass@c:3"} {
+		u, err := parseProxy(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls = append(urls, u)
+	}
+	logger := newLogger(nil)
+
+	pool := newProxyPool(urls, proxyPolicy{rotation: RotateRoundRobin}, logger)
+	var got []string
+	for i := 0; i < 4; i++ {
+		s, _ := pool.pick("translate.google.com")
+		got = append(got, s.url.Host)
+	}
+	if strings.Join(got, ",") != "a:1,b:2,c:3,a:1" {
+		t.Errorf("round robin order %v", got)
+	}
+
+	pool = newProxyPool(urls, proxyPolicy{rotation: RotateSticky}, logger)
+	first, _ := pool.pick("translate.google.com")
+	other, _ := pool.pick("translate.google.de")
+	again, _ := pool.pick("translate.google.com")
+	if first != again || first == other {
+		t.Errorf("sticky picked %s, %s, %s", first.url.Host, other.url.Host, again.url.Host)
+	}
+
+	for _, p := range []string{"ftp://a:1", "http://", "socks4://a:1"} {
+		if _, err := parseProxy(p); err == nil {
+			t.Errorf("parseProxy(%q) succeeded", p)
+		}
+	}
+	if _, err := parseProxy("http://user:secret@a:1/%zz"); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("invalid URL error %v", err)
+	}
+}
+
+func TestProxyPool_Eviction(t *testing.T) {
+	a, _ := parseProxy("http://a:1")
+	b, _ := parseProxy("http://b:2")
+	pool := newProxyPool([]*url.URL{a, b}, proxyPolicy{maxFailures: 2, evictFor: time.Hour}, newLogger(nil))
+	ctx := context.Background()
+	connErr := &url.Error{Op: "Get", URL: "https://translate.google.com", Err: &net.OpError{Op: "proxyconnect", Net: "tcp", Err: errors.New("connection refused")}}
+	tlsErr := &url.Error{Op: "Get", URL: "https://translate.google.com", Err: errors.New("tls: handshake failure")}
+
+	pa, _ := pool.pick("h")
+	pb, _ := pool.pick("h")
+	pool.report(ctx, pa, connErr)
+	pool.report(ctx, pb, &BlockedError{Kind: BlockCaptcha})
+	if s, err := pool.pick("h"); err != nil || s != pa {
+		t.Fatalf("after one connection error got %v, %v; want a", s, err)
+	}
+	pool.report(ctx, pa, tlsErr)
+	pool.report(ctx, pa, tlsErr)
+	if s, err := pool.pick("h"); err != nil || s != pa {
+		t.Fatalf("after errors not caused by the proxy got %v, %v; want a", s, err)
+	}
+	pool.report(ctx, pa, connErr)
+	if _, err := pool.pick("h"); !errors.Is(err, ErrNoProxy) {
+		t.Fatalf("all proxies evicted: got %v", err)
+	}
+
+	status := pool.status()
+	if len(status) != 2 || status[0].Failures != 2 || status[1].Failures != 1 || status[0].EvictedUntil.IsZero() || status[1].EvictedUntil.IsZero() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	pool.proxies[1].evictedUntil = time.Now()
+	if s, err := pool.pick("h"); err != nil || s != pb {
+		t.Fatalf("after eviction ended got %v, %v; want b", s, err)
+	}
+}
+
+func TestTranslate_ProxyPool(t *testing.T) {
+	good, tunnels := connectProxy(t)
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	}), Config{
+		Proxies:          []string{dead.URL, good.URL},
+		ProxyMaxFailures: 1,
+	})
+
+	if _, err := trans.Translate("hello", "en", "es"); err == nil {
+		t.Fatal("expected a connection error through the dead proxy")
+	}
+	result, err := trans.Translate("hello", "en", "es")
+	if err != nil || result.Text != "hola" {
+		t.Fatalf("got %v, %v", result, err)
+	}
+	if tunnels.Load() == 0 {
+		t.Fatal("no request went through the working proxy")
+	}
+
+	status := trans.ProxyStatus()
+	if len(status) != 2 || status[0].EvictedUntil.IsZero() || !status[1].EvictedUntil.IsZero() || status[1].Successes == 0 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	if err := trans.SetProxy("ftp://proxy:21"); err == nil {
+		t.Fatal("SetProxy accepted an ftp proxy")
+	}
+	if err := trans.SetProxy(""); err != nil || trans.ProxyStatus() != nil {
+		t.Fatalf("SetProxy(\"\"): %v, %+v", err, trans.ProxyStatus())
+	}
+}
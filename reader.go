@@ -0,0 +1,187 @@
+package translator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultChunkSize = 2000
+
+// ReaderConfig configures TranslateReader.
+type ReaderConfig struct {
+	// ChunkSize is the maximum size in bytes of the text sent per request. Defaults to 2000.
+	ChunkSize int
+	// Concurrency is the number of chunks translated at the same time. Defaults to 4.
+	Concurrency int
+}
+
+// readerChunk is a chunk of input waiting for its translation. The whitespace
+// around the text is kept aside, as the translation does not preserve it.
+type readerChunk struct {
+	prefix, suffix string
+	blank          bool // nothing to translate, prefix holds the whole chunk
+}
+
+// sentenceEnds are the marks ending a sentence, in the form they are searched for.
+var sentenceEnds = [][]byte{[]byte(". "), []byte("! "), []byte("? "), []byte("。"), []byte("！"), []byte("？")}
+
+// TranslateReader translates the text read from r and writes the translation
+// to w. The input is cut into chunks of at most config.ChunkSize bytes, at
+// paragraph breaks where possible, else at line breaks, sentence ends or
+// spaces. Chunks are translated config.Concurrency at a time and written in
+// order, so memory use depends on these settings and not on the input size.
+//
+// The first failing chunk stops the translation and its error is returned;
+// what was translated before it has been written to w. r is only read by the
+// calling goroutine, so the error is returned once a read in progress returns,
+// and nothing is read from r afterwards.
+//
+// Example Usage:
+//
+//	in, _ := os.Open("notes.txt")
+//	defer in.Close()
+//	err := translator.TranslateReader(ctx, in, os.Stdout, "en", "es")
+func (a *Translator) TranslateReader(ctx context.Context, r io.Reader, w io.Writer, src, dest string, config ...ReaderConfig) error {
+	var c ReaderConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.ChunkSize <= 0 {
+		c.ChunkSize = defaultChunkSize
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultStreamConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, c.ChunkSize+1), c.ChunkSize+1)
+	scanner.Split(splitChunks(c.ChunkSize))
+
+	// The chunks are queued in input order before their jobs are sent, so they
+	// match the ordered results one to one. The queue bounds the read-ahead.
+	jobs := make(chan Job)
+	pending := make(chan readerChunk, 2*c.Concurrency)
+	results := a.TranslateStream(ctx, jobs, StreamConfig{Concurrency: c.Concurrency, Ordered: true})
+	written := make(chan error, 1)
+	go func() {
+		err := writeChunks(ctx, w, pending, results)
+		if err != nil {
+			cancel()
+		}
+		written <- err
+	}()
+
+	// r is only read here, so no read is left behind once TranslateReader returns.
+	func() {
+		defer close(pending)
+		defer close(jobs)
+		for ctx.Err() == nil && scanner.Scan() {
+			text := scanner.Text()
+			body := strings.TrimSpace(text)
+			chunk := readerChunk{prefix: text, blank: body == ""}
+			if !chunk.blank {
+				start := strings.Index(text, body)
+				chunk.prefix, chunk.suffix = text[:start], text[start+len(body):]
+			}
+			select {
+			case pending <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			if chunk.blank {
+				continue
+			}
+			select {
+			case jobs <- Job{Text: body, Src: src, Dest: dest}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	if err := <-written; err != nil {
+		return err
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// writeChunks writes the queued chunks to w, the translation of each non-blank
+// one taken from results, until pending is closed or an error occurs.
+func writeChunks(ctx context.Context, w io.Writer, pending <-chan readerChunk, results <-chan Result) error {
+	for chunk := range pending {
+		var text string
+		if !chunk.blank {
+			result, ok := <-results
+			if !ok {
+				return ctx.Err()
+			}
+			if result.Err != nil {
+				return result.Err
+			}
+			text = result.Translated.Text
+		}
+		if _, err := io.WriteString(w, chunk.prefix+text+chunk.suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitChunks returns a bufio.SplitFunc cutting text into chunks of at most
+// size bytes.
+func splitChunks(size int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) <= size {
+			if atEOF && len(data) > 0 {
+				return len(data), data, nil
+			}
+			return 0, nil, nil
+		}
+		n := chunkEnd(data, size)
+		return n, data[:n], nil
+	}
+}
+
+// chunkEnd returns where to cut data, which is longer than size: after the
+// strongest boundary found in the second half of the first size bytes, from a
+// paragraph break down to a space, else at the last rune boundary that fits.
+func chunkEnd(data []byte, size int) int {
+	half := size / 2
+	window := data[half:size]
+	if i := bytes.LastIndex(window, []byte("\n\n")); i >= 0 {
+		return half + i + 2
+	}
+	if i := bytes.LastIndexByte(window, '\n'); i >= 0 {
+		return half + i + 1
+	}
+	end := -1
+	for _, mark := range sentenceEnds {
+		if i := bytes.LastIndex(window, mark); i >= 0 && i+len(mark) > end {
+			end = i + len(mark)
+		}
+	}
+	if end >= 0 {
+		return half + end
+	}
+	if i := bytes.LastIndexAny(window, " \t"); i >= 0 {
+		return half + i + 1
+	}
+	n := size
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	if n == 0 {
+		return size
+	}
+	return n
+}
@@ -0,0 +1,122 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChunkEnd(t *testing.T) {
+	tests := []struct {
+		data string
+		size int
+		want string
+	}{
+		{"first paragraph.\n\nsecond line\nthird line", 24, "first paragraph.\n\n"},
+		{"one line here!\nanother. Sentence here", 24, "one line here!\n"},
+		{"A sentence here. Another one, and more words", 30, "A sentence here. "},
+		{"no sentence end but words here", 20, "no sentence end but "},
+		{"aaaaaaaaaaaaaaaaaaaaaa", 10, "aaaaaaaaaa"},
+		{"ééééééééé", 5, "éé"},
+	}
+	for _, tt := range tests {
+		if got := tt.data[:chunkEnd([]byte(tt.data), tt.size)]; got != tt.want {
+			t.Errorf("chunkEnd(%q, %d) cuts %q, want %q", tt.data, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateReader(t *testing.T) {
+	var hits atomic.Int32
+	var longest atomic.Int32
+	upper := upperHandler(&hits, false)
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := int32(len(r.URL.Query().Get("q"))); n > longest.Load() {
+			longest.Store(n)
+		}
+		upper.ServeHTTP(w, r)
+	}))
+
+	var input strings.Builder
+	for i := 0; i < 40; i++ {
+		input.WriteString("  The first sentence of a paragraph. A second one follows!\nAnd a line.\n\n")
+	}
+	input.WriteString("\n\n\n")
+
+	var out bytes.Buffer
+	err := trans.TranslateReader(context.Background(), strings.NewReader(input.String()), &out, "en", "es", ReaderConfig{ChunkSize: 200, Concurrency: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != strings.ToUpper(input.String()) {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+	if n := longest.Load(); n > 200 {
+		t.Fatalf("chunk of %d bytes sent, want at most 200", n)
+	}
+	if hits.Load() < int32(input.Len()/200) {
+		t.Fatalf("only %d requests", hits.Load())
+	}
+}
+
+func TestTranslateReader_Error(t *testing.T) {
+	var hits atomic.Int32
+	upper := upperHandler(&hits, false)
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("q"), "bad") {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		upper.ServeHTTP(w, r)
+	}))
+
+	input := strings.Repeat("good text.\n\n", 10) + "bad text.\n\n" + strings.Repeat("good text.\n\n", 10)
+	var out bytes.Buffer
+	err := trans.TranslateReader(context.Background(), strings.NewReader(input), &out, "en", "es", ReaderConfig{ChunkSize: 24})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got error %v", err)
+	}
+	if out.String() != strings.Repeat("GOOD TEXT.\n\n", 10) {
+		t.Fatalf("unexpected output before the error:\n%s", out.String())
+	}
+}
+
+// slowReader returns data, then blocks each further read for delay.
+type slowReader struct {
+	data  string
+	delay time.Duration
+	reads atomic.Int32
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	defer r.reads.Add(1)
+	if r.reads.Load() > 0 {
+		time.Sleep(r.delay)
+		return copy(p, "more text.\n\n"), nil
+	}
+	return copy(p, r.data), nil
+}
+
+func TestTranslateReader_NoReadAfterReturn(t *testing.T) {
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+
+	r := &slowReader{data: strings.Repeat("bad text.\n\n", 10), delay: 50 * time.Millisecond}
+	err := trans.TranslateReader(context.Background(), r, io.Discard, "en", "es", ReaderConfig{ChunkSize: 24})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	reads := r.reads.Load()
+	time.Sleep(100 * time.Millisecond)
+	if r.reads.Load() != reads {
+		t.Fatalf("r was read after TranslateReader returned: %d reads, then %d", reads, r.reads.Load())
+	}
+}
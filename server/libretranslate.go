@@ -0,0 +1,311 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+// LibreTranslate uses its own codes for a few languages.
+var (
+	libreToGoogle = map[string]string{
+		"zh":      "zh-cn",
+		"zh-hans": "zh-cn",
+		"zt":      "zh-tw",
+		"zh-hant": "zh-tw",
+	}
+	googleToLibre = map[string]string{
+		"zh-cn": "zh",
+		"zh-tw": "zt",
+		"iw":    "he",
+	}
+)
+
+type libreLanguage struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Targets []string `json:"targets"`
+}
+
+type libreDetection struct {
+	Confidence float64 `json:"confidence"`
+	Language   string  `json:"language"`
+}
+
+type libreError struct {
+	Error string `json:"error"`
+}
+
+// registerLibreTranslate adds the LibreTranslate compatible endpoints.
+func (h *Handler) registerLibreTranslate() {
+	h.mux.HandleFunc("/translate", h.libre(http.MethodPost, h.handleLibreTranslate))
+	h.mux.HandleFunc("/detect", h.libre(http.MethodPost, h.handleLibreDetect))
+	h.mux.HandleFunc("/languages", h.libre(http.MethodGet, h.handleLibreLanguages))
+}
+
+// libre wraps a LibreTranslate endpoint with CORS handling, as browser
+// extensions call these endpoints directly, and a method check.
+func (h *Handler) libre(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", method+", OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeJSON(w, http.StatusMethodNotAllowed, libreError{"Method not allowed"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *Handler) handleLibreTranslate(w http.ResponseWriter, r *http.Request) {
+	params, err := h.libreParams(w, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, libreError{err.Error()})
+		return
+	}
+	q, batch, err := params.texts()
+	if err == nil && len(q) > h.config.MaxBatchSize {
+		err = errors.New("Too many texts")
+	}
+	for i := 0; err == nil && i < len(q); i++ {
+		err = h.validText(q[i])
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, libreError{err.Error()})
+		return
+	}
+	if params.Format != "" && params.Format != "text" {
+		writeJSON(w, http.StatusBadRequest, libreError{"Unsupported format: " + params.Format})
+		return
+	}
+	if params.Source == "" {
+		writeJSON(w, http.StatusBadRequest, libreError{"Invalid request: missing source parameter"})
+		return
+	}
+	if params.Target == "" {
+		writeJSON(w, http.StatusBadRequest, libreError{"Invalid request: missing target parameter"})
+		return
+	}
+	src, dest, err := validLanguages(fromLibreCode(params.Source), fromLibreCode(params.Target))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, libreError{err.Error()})
+		return
+	}
+
+	texts := make([]string, len(q))
+	detections := make([]libreDetection, len(q))
+	for i, text := range q {
+		if err := h.acquire(r.Context()); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, libreError{err.Error()})
+			return
+		}
+		if src == "auto" {
+			// Detection translates as well, so one upstream call is enough.
+			var detected translator.LDResponse
+			detected, err = h.backend.DetectLanguageContext(r.Context(), text, dest)
+			if err == nil {
+				texts[i] = joinSentences(detected)
+				detections[i] = libreDetection{detected.Confidence * 100, toLibreCode(detected.Src)}
+			}
+		} else {
+			var result *translator.Translated
+			result, err = h.backend.TranslateContext(r.Context(), text, src, dest)
+			if err == nil {
+				texts[i] = result.Text
+			}
+		}
+		h.release()
+		if err != nil {
+			status, msg := upstreamError(err)
+			writeJSON(w, status, libreError{msg})
+			return
+		}
+	}
+
+	resp := map[string]interface{}{}
+	if batch {
+		resp["translatedText"] = texts
+		if src == "auto" {
+			resp["detectedLanguage"] = detections
+		}
+	} else {
+		resp["translatedText"] = texts[0]
+		if src == "auto" {
+			resp["detectedLanguage"] = detections[0]
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleLibreDetect(w http.ResponseWriter, r *http.Request) {
+	params, err := h.libreParams(w, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, libreError{err.Error()})
+		return
+	}
+	q, _, err := params.texts()
+	if err == nil && len(q) != 1 {
+		err = errors.New("Invalid request: q must be a single text")
+	}
+	if err == nil {
+		err = h.validText(q[0])
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, libreError{err.Error()})
+		return
+	}
+
+	if err := h.acquire(r.Context()); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, libreError{err.Error()})
+		return
+	}
+	detected, err := h.backend.DetectLanguageContext(r.Context(), q[0], detectDest)
+	h.release()
+	if err != nil {
+		status, msg := upstreamError(err)
+		writeJSON(w, status, libreError{msg})
+		return
+	}
+
+	resp := []libreDetection{{detected.Confidence * 100, toLibreCode(detected.Src)}}
+	ld := detected.LdResult
+	for i, lang := range ld.Srclangs {
+		if lang == detected.Src || i >= len(ld.SrclangsConfidences) {
+			continue
+		}
+		resp = append(resp, libreDetection{ld.SrclangsConfidences[i] * 100, toLibreCode(lang)})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleLibreLanguages(w http.ResponseWriter, _ *http.Request) {
+	available := h.backend.GetAvaliableLanguages()
+
+	seen := map[string]bool{}
+	var codes []string
+	for code := range available {
+		lc := toLibreCode(code)
+		if code == "auto" || seen[lc] {
+			continue
+		}
+		seen[lc] = true
+		codes = append(codes, lc)
+	}
+	sort.Strings(codes)
+
+	langs := make([]libreLanguage, 0, len(codes))
+	for _, lc := range codes {
+		name := available[fromLibreCode(lc)]
+		langs = append(langs, libreLanguage{Code: lc, Name: titleCase(name), Targets: codes})
+	}
+	writeJSON(w, http.StatusOK, langs)
+}
+
+// libreRequest holds the parameters LibreTranslate accepts, either as a JSON
+// body or as form values.
+type libreRequest struct {
+	Q      json.RawMessage `json:"q"`
+	Source string          `json:"source"`
+	Target string          `json:"target"`
+	Format string          `json:"format"`
+	APIKey string          `json:"api_key"`
+
+	form []string
+}
+
+// libreParams parses a LibreTranslate request body.
+func (h *Handler) libreParams(w http.ResponseWriter, r *http.Request) (*libreRequest, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxBodyBytes)
+	params := &libreRequest{}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(params); err != nil {
+			return nil, errors.New("Invalid request: " + err.Error())
+		}
+		return params, nil
+	}
+
+	var err error
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(h.config.MaxBodyBytes)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return nil, errors.New("Invalid request: " + err.Error())
+	}
+	params.form = r.Form["q"]
+	params.Source = r.FormValue("source")
+	params.Target = r.FormValue("target")
+	params.Format = r.FormValue("format")
+	params.APIKey = r.FormValue("api_key")
+	return params, nil
+}
+
+// texts returns the texts to handle and whether q was given as an array.
+func (p *libreRequest) texts() ([]string, bool, error) {
+	missing := errors.New("Invalid request: missing q parameter")
+	if p.Q == nil {
+		if len(p.form) == 0 {
+			return nil, false, missing
+		}
+		return p.form, len(p.form) > 1, nil
+	}
+
+	var single string
+	if err := json.Unmarshal(p.Q, &single); err == nil {
+		return []string{single}, false, nil
+	}
+	var many []string
+	if err := json.Unmarshal(p.Q, &many); err != nil || len(many) == 0 {
+		return nil, false, missing
+	}
+	return many, true, nil
+}
+
+func joinSentences(detected translator.LDResponse) string {
+	var sb strings.Builder
+	for _, s := range detected.Sentences {
+		sb.WriteString(s.Trans)
+	}
+	return sb.String()
+}
+
+func fromLibreCode(code string) string {
+	code = strings.ToLower(code)
+	if google, ok := libreToGoogle[code]; ok {
+		return google
+	}
+	return code
+}
+
+func toLibreCode(code string) string {
+	if libre, ok := googleToLibre[code]; ok {
+		return libre
+	}
+	return code
+}
+
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		if w[0] == '(' && len(w) > 1 {
+			words[i] = "(" + strings.ToUpper(w[1:2]) + w[2:]
+			continue
+		}
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLibreTranslate_Disabled(t *testing.T) {
+	h := New(&fakeBackend{})
+	if rec := do(h, "POST", "/translate", `{"q":"hola","source":"es","target":"en"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404 when LibreTranslate is off", rec.Code)
+	}
+}
+
+func TestLibreTranslate_Translate(t *testing.T) {
+	h := New(&fakeBackend{}, Config{LibreTranslate: true})
+
+	req := httptest.NewRequest("POST", "/translate", strings.NewReader(`{"q":"hola","source":"es","target":"zh"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"translatedText":"HOLA"}`+"\n" {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+
+	form := url.Values{"q": {"hola"}, "source": {"auto"}, "target": {"en"}}
+	req = httptest.NewRequest("POST", "/translate", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var resp struct {
+		TranslatedText   string         `json:"translatedText"`
+		DetectedLanguage libreDetection `json:"detectedLanguage"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.DetectedLanguage.Language != "es" || resp.DetectedLanguage.Confidence != 90 {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+
+	req = httptest.NewRequest("POST", "/translate", strings.NewReader(`{"q":["a","b"],"source":"en","target":"es"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"translatedText":["A","B"]}`+"\n" {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+
+	for _, body := range []string{
+		`{"source":"en","target":"es"}`,
+		`{"q":"a","target":"es"}`,
+		`{"q":"a","source":"en","target":"xx"}`,
+	} {
+		req = httptest.NewRequest("POST", "/translate", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"error"`) {
+			t.Errorf("%s: status %d, body %s", body, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestLibreTranslate_DetectAndLanguages(t *testing.T) {
+	h := New(&fakeBackend{}, Config{LibreTranslate: true})
+
+	req := httptest.NewRequest("POST", "/detect", strings.NewReader("q=hola"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	var detections []libreDetection
+	json.Unmarshal(rec.Body.Bytes(), &detections)
+	if rec.Code != http.StatusOK || len(detections) != 2 || detections[0].Language != "es" {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+
+	rec = do(h, "GET", "/languages", "")
+	var langs []libreLanguage
+	json.Unmarshal(rec.Body.Bytes(), &langs)
+	if rec.Code != http.StatusOK || len(langs) != 2 || langs[1].Name != "Spanish" || len(langs[1].Targets) != 2 {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+
+	if rec := do(h, "OPTIONS", "/translate", ""); rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("preflight status %d, headers %v", rec.Code, rec.Header())
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	if got := titleCase("chinese (simplified)"); got != "Chinese (Simplified)" {
+		t.Fatalf("titleCase = %q", got)
+	}
+}
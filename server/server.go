@@ -0,0 +1,394 @@
+// Package server exposes a translator.Translator as a JSON HTTP service.
+//
+// The Handler serves the following endpoints:
+//
+//	POST /v1/translate        {"text", "src", "dest"}
+//	POST /v1/translate/batch  {"texts", "src", "dest"}
+//	POST /v1/detect           {"text"}
+//	GET  /v1/languages
+//	GET  /healthz             liveness, always 200 while the process runs
+//	GET  /readyz              readiness, 503 once SetReady(false) was called
+//
+// Language codes and names are validated with translator.GetValidLanguageKey
+// before any upstream request is made.
+//
+// The service reports the language of a text only along with a translation,
+// so a detection costs one upstream translation request, to English, like a
+// translation of the same text.
+//
+// With Config.LibreTranslate set, the Handler also serves /translate, /detect
+// and /languages using LibreTranslate's request and response format, so tools
+// written against that API can be pointed at it unchanged.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+const (
+	defaultMaxConcurrency = 8
+	defaultMaxBatchSize   = 100
+	defaultMaxTextLength  = 5000
+	defaultMaxBodyBytes   = 1 << 20
+)
+
+// Backend is the subset of *translator.Translator used by the Handler.
+type Backend interface {
+	TranslateContext(ctx context.Context, origin, src, dest string) (*translator.Translated, error)
+	DetectLanguageContext(ctx context.Context, origin, dest string) (translator.LDResponse, error)
+	GetAvaliableLanguages() map[string]string
+}
+
+// Config basic opts.
+type Config struct {
+	MaxConcurrency int   // upstream requests in flight at once, across all clients
+	MaxBatchSize   int   // texts accepted by a single batch request
+	MaxTextLength  int   // characters accepted per text
+	MaxBodyBytes   int64 // size limit for request bodies
+	LibreTranslate bool  // also serve the LibreTranslate compatible endpoints
+}
+
+// Handler is an http.Handler serving the translation API.
+type Handler struct {
+	backend Backend
+	config  Config
+	sem     chan struct{}
+	ready   atomic.Bool
+	mux     *http.ServeMux
+}
+
+// New creates a Handler backed by the given Backend, usually a *translator.Translator.
+// Zero values in config are replaced by defaults. The Handler starts out ready.
+func New(backend Backend, config ...Config) *Handler {
+	var c Config
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.MaxConcurrency <= 0 {
+		c.MaxConcurrency = defaultMaxConcurrency
+	}
+	if c.MaxBatchSize <= 0 {
+		c.MaxBatchSize = defaultMaxBatchSize
+	}
+	if c.MaxTextLength <= 0 {
+		c.MaxTextLength = defaultMaxTextLength
+	}
+	if c.MaxBodyBytes <= 0 {
+		c.MaxBodyBytes = defaultMaxBodyBytes
+	}
+
+	h := &Handler{
+		backend: backend,
+		config:  c,
+		sem:     make(chan struct{}, c.MaxConcurrency),
+		mux:     http.NewServeMux(),
+	}
+	h.ready.Store(true)
+
+	h.mux.HandleFunc("/v1/translate", h.post(h.handleTranslate))
+	h.mux.HandleFunc("/v1/translate/batch", h.post(h.handleBatch))
+	h.mux.HandleFunc("/v1/detect", h.post(h.handleDetect))
+	h.mux.HandleFunc("/v1/languages", h.handleLanguages)
+	h.mux.HandleFunc("/healthz", h.handleHealth)
+	h.mux.HandleFunc("/readyz", h.handleReady)
+	if c.LibreTranslate {
+		h.registerLibreTranslate()
+	}
+	return h
+}
+
+// SetReady changes what /readyz reports. Servers call SetReady(false) when
+// they start shutting down so load balancers stop routing to them.
+func (h *Handler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+type translateRequest struct {
+	Text string `json:"text"`
+	Src  string `json:"src"`
+	Dest string `json:"dest"`
+}
+
+type translateResponse struct {
+	Src    string `json:"src"`
+	Dest   string `json:"dest"`
+	Origin string `json:"origin"`
+	Text   string `json:"text"`
+}
+
+type batchRequest struct {
+	Texts []string `json:"texts"`
+	Src   string   `json:"src"`
+	Dest  string   `json:"dest"`
+}
+
+type batchItem struct {
+	Text  string `json:"text,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Src          string      `json:"src"`
+	Dest         string      `json:"dest"`
+	Translations []batchItem `json:"translations"`
+}
+
+type detectRequest struct {
+	Text string `json:"text"`
+}
+
+type alternative struct {
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+}
+
+type detectResponse struct {
+	Src          string        `json:"src"`
+	Confidence   float64       `json:"confidence"`
+	Alternatives []alternative `json:"alternatives,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *Handler) handleTranslate(w http.ResponseWriter, r *http.Request) {
+	var req translateRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	src, dest, err := validLanguages(req.Src, req.Dest)
+	if err == nil {
+		err = h.validText(req.Text)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.acquire(r.Context()); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	result, err := h.backend.TranslateContext(r.Context(), req.Text, src, dest)
+	h.release()
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, translateResponse{result.Src, result.Dest, result.Origin, result.Text})
+}
+
+func (h *Handler) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	src, dest, err := validLanguages(req.Src, req.Dest)
+	if err == nil && len(req.Texts) == 0 {
+		err = errors.New("texts must not be empty")
+	}
+	if err == nil && len(req.Texts) > h.config.MaxBatchSize {
+		err = errors.New("too many texts in batch")
+	}
+	for i := 0; err == nil && i < len(req.Texts); i++ {
+		err = h.validText(req.Texts[i])
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Items share the handler wide concurrency limit with every other request.
+	items := make([]batchItem, len(req.Texts))
+	var wg sync.WaitGroup
+	for i, text := range req.Texts {
+		if err := h.acquire(r.Context()); err != nil {
+			wg.Wait()
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			defer h.release()
+			result, err := h.backend.TranslateContext(r.Context(), text, src, dest)
+			if err != nil {
+				_, items[i].Error = upstreamError(err)
+				return
+			}
+			items[i].Text = result.Text
+		}(i, text)
+	}
+	wg.Wait()
+	writeJSON(w, http.StatusOK, batchResponse{Src: src, Dest: dest, Translations: items})
+}
+
+// detectDest is the language detected texts are translated to, as the service
+// only detects languages while translating. The translation is discarded.
+const detectDest = "en"
+
+func (h *Handler) handleDetect(w http.ResponseWriter, r *http.Request) {
+	var req detectRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+	if err := h.validText(req.Text); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.acquire(r.Context()); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	detected, err := h.backend.DetectLanguageContext(r.Context(), req.Text, detectDest)
+	h.release()
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+
+	resp := detectResponse{Src: detected.Src, Confidence: detected.Confidence}
+	ld := detected.LdResult
+	for i, lang := range ld.Srclangs {
+		if lang == detected.Src || i >= len(ld.SrclangsConfidences) {
+			continue
+		}
+		resp.Alternatives = append(resp.Alternatives, alternative{lang, ld.SrclangsConfidences[i]})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) handleLanguages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"languages": h.backend.GetAvaliableLanguages()})
+}
+
+func (h *Handler) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *Handler) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if !h.ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// post restricts a handler to POST requests.
+func (h *Handler) post(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// acquire waits for a free upstream slot or for ctx to be done.
+func (h *Handler) acquire(ctx context.Context) error {
+	select {
+	case h.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return errors.New("server busy: " + ctx.Err().Error())
+	}
+}
+
+func (h *Handler) release() {
+	<-h.sem
+}
+
+// decode reads a JSON body into v, writing a 400 response and returning false on failure.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, h.config.MaxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request body: "+err.Error()))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) validText(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("text must not be empty")
+	}
+	if len([]rune(text)) > h.config.MaxTextLength {
+		return errors.New("text is too long")
+	}
+	return nil
+}
+
+// validLanguages normalises src and dest, defaulting to auto and en.
+func validLanguages(src, dest string) (string, string, error) {
+	if src == "" {
+		src = "auto"
+	}
+	if dest == "" {
+		dest = "en"
+	}
+	src, err := translator.GetValidLanguageKey(src)
+	if err != nil {
+		return "", "", err
+	}
+	dest, err = translator.GetValidLanguageKey(dest)
+	if err != nil {
+		return "", "", err
+	}
+	if dest == "auto" {
+		return "", "", errors.New("destination language cannot be auto")
+	}
+	return src, dest, nil
+}
+
+// upstreamError picks the status code and message reported for a failed
+// upstream call. The library error itself is not exposed to clients.
+func upstreamError(err error) (int, string) {
+	switch {
+	case errors.Is(err, translator.ErrRateLimited):
+		return http.StatusTooManyRequests, "rate limited by upstream service"
+	case errors.Is(err, translator.ErrCaptcha), errors.Is(err, translator.ErrConsent):
+		return http.StatusBadGateway, "blocked by upstream service"
+	case errors.Is(err, translator.ErrInvalidLanguage):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, translator.ErrCircuitOpen):
+		return http.StatusServiceUnavailable, "upstream service unavailable"
+	}
+	return http.StatusBadGateway, "upstream request failed"
+}
+
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	status, msg := upstreamError(err)
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
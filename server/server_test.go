@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+type fakeBackend struct {
+	delay    time.Duration
+	inFlight atomic.Int32
+	maxSeen  atomic.Int32
+}
+
+func (f *fakeBackend) TranslateContext(_ context.Context, origin, src, dest string) (*translator.Translated, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		seen := f.maxSeen.Load()
+		if n <= seen || f.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+	if origin == "fail" {
+		return nil, &translator.HTTPError{StatusCode: 429, Host: "translate.google.com", Body: "secret"}
+	}
+	return &translator.Translated{Src: src, Dest: dest, Origin: origin, Text: strings.ToUpper(origin)}, nil
+}
+
+func (f *fakeBackend) DetectLanguageContext(_ context.Context, origin, dest string) (translator.LDResponse, error) {
+	return translator.LDResponse{
+		Src:        "es",
+		Confidence: 0.9,
+		LdResult: translator.LDResult{
+			Srclangs:            []string{"es", "pt"},
+			SrclangsConfidences: []float64{0.9, 0.1},
+		},
+	}, nil
+}
+
+func (f *fakeBackend) GetAvaliableLanguages() map[string]string {
+	return map[string]string{"en": "english", "es": "spanish"}
+}
+
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_Translate(t *testing.T) {
+	h := New(&fakeBackend{})
+
+	rec := do(h, "POST", "/v1/translate", `{"text":"hola","src":"spanish","dest":"EN"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp translateResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.Src != "es" || resp.Dest != "en" || resp.Text != "HOLA" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	for _, body := range []string{
+		`{"text":"hola","dest":"klingon"}`,
+		`{"text":"hola","dest":"auto"}`,
+		`{"text":"   "}`,
+		`{"text":"hola","unknown":1}`,
+		`not json`,
+	} {
+		if rec := do(h, "POST", "/v1/translate", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, rec.Code)
+		}
+	}
+
+	if rec := do(h, "GET", "/v1/translate", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status %d, want 405", rec.Code)
+	}
+
+	rec = do(h, "POST", "/v1/translate", `{"text":"fail"}`)
+	if rec.Code != http.StatusTooManyRequests || strings.Contains(rec.Body.String(), "secret") {
+		t.Errorf("status %d, body %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHandler_BatchConcurrencyLimit(t *testing.T) {
+	backend := &fakeBackend{delay: 10 * time.Millisecond}
+	h := New(backend, Config{MaxConcurrency: 2, MaxBatchSize: 3})
+
+	rec := do(h, "POST", "/v1/translate/batch", `{"texts":["a","b","fail"],"dest":"en"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp batchResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if len(resp.Translations) != 3 || resp.Translations[1].Text != "B" || resp.Translations[2].Error == "" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if got := backend.maxSeen.Load(); got > 2 {
+		t.Fatalf("%d requests in flight, limit is 2", got)
+	}
+
+	rec = do(h, "POST", "/v1/translate/batch", `{"texts":["a","b","c","d"]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("oversized batch status %d, want 400", rec.Code)
+	}
+}
+
+func TestHandler_DetectAndProbes(t *testing.T) {
+	h := New(&fakeBackend{})
+
+	rec := do(h, "POST", "/v1/detect", `{"text":"hola"}`)
+	var resp detectResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusOK || resp.Src != "es" || len(resp.Alternatives) != 1 {
+		t.Fatalf("status %d, response %+v", rec.Code, resp)
+	}
+
+	if rec := do(h, "GET", "/v1/languages", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "spanish") {
+		t.Fatalf("languages: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := do(h, "GET", "/readyz", ""); rec.Code != http.StatusOK {
+		t.Fatalf("readyz status %d", rec.Code)
+	}
+	h.SetReady(false)
+	if rec := do(h, "GET", "/readyz", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz after SetReady(false) status %d", rec.Code)
+	}
+	if rec := do(h, "GET", "/healthz", ""); rec.Code != http.StatusOK {
+		t.Fatalf("healthz status %d", rec.Code)
+	}
+}
@@ -0,0 +1,243 @@
+package translator
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+const defaultStreamConcurrency = 4
+
+// Job is a text to translate in a stream.
+type Job struct {
+	ID   string // correlation ID, copied to the Result
+	Text string
+	Src  string // source language, "auto" when empty
+	Dest string
+}
+
+// Result is the outcome of a Job. Err is set when the job failed; the stream
+// goes on with the next jobs.
+type Result struct {
+	ID         string // ID of the job
+	Index      int    // position of the job in the stream, starting at 0
+	Translated *Translated
+	Err        error
+}
+
+// StreamConfig configures TranslateStream.
+type StreamConfig struct {
+	// Concurrency is the number of jobs translated at the same time. Defaults to 4.
+	Concurrency int
+	// Ordered delivers the results in the order of the jobs instead of as soon as they are done.
+	Ordered bool
+}
+
+// Future is the pending result of TranslateAsync.
+type Future struct {
+	done   chan struct{}
+	result *Translated
+	err    error
+}
+
+// Done returns a channel closed once the translation has finished.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the translation has finished and returns its result.
+func (f *Future) Wait() (*Translated, error) {
+	<-f.done
+	return f.result, f.err
+}
+
+// TranslateAsync starts translating origin in the background and returns at
+// once. Cancelling ctx aborts the translation.
+//
+// Example Usage:
+//
+//	future := translator.TranslateAsync(ctx, "Hello", "en", "es")
+//	// ... do other work ...
+//	translated, err := future.Wait()
+func (a *Translator) TranslateAsync(ctx context.Context, origin, src, dest string) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.result, f.err = a.TranslateContext(ctx, origin, src, dest)
+	}()
+	return f
+}
+
+// TranslateStream translates the jobs received from in, at most
+// config.Concurrency at a time, and sends a Result for each of them on the
+// returned channel. The channel is closed once in is closed and every job has
+// been handled.
+//
+// Once ctx is done no further jobs are read, the jobs in flight fail with the
+// context error and undelivered results are dropped, so callers that stop
+// reading early should cancel ctx.
+//
+// Example Usage:
+//
+//	jobs := make(chan translator.Job)
+//	go func() {
+//	  defer close(jobs)
+//	  for id, text := range texts {
+//	    jobs <- translator.Job{ID: id, Text: text, Src: "en", Dest: "es"}
+//	  }
+//	}()
+//	for result := range translator.TranslateStream(ctx, jobs) {
+//	  if result.Err != nil {
+//	    log.Printf("%s: %v", result.ID, result.Err)
+//	    continue
+//	  }
+//	  fmt.Println(result.ID, result.Translated.Text)
+//	}
+func (a *Translator) TranslateStream(ctx context.Context, in <-chan Job, config ...StreamConfig) <-chan Result {
+	var c StreamConfig
+	if len(config) > 0 {
+		c = config[0]
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultStreamConcurrency
+	}
+
+	out := make(chan Result, c.Concurrency)
+	go func() {
+		defer close(out)
+		send := func(r Result) {
+			select {
+			case out <- r:
+			case <-ctx.Done():
+			}
+		}
+
+		// In ordered mode every job gets a slot, queued in job order; the writer
+		// delivers the slots one by one as they are filled. The bounded queue
+		// keeps the number of buffered results in check when an early job is slow.
+		var queue chan chan Result
+		written := make(chan struct{})
+		if c.Ordered {
+			queue = make(chan chan Result, c.Concurrency)
+			go func() {
+				defer close(written)
+				for slot := range queue {
+					send(<-slot)
+				}
+			}()
+		} else {
+			close(written)
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, c.Concurrency)
+	loop:
+		for i := 0; ctx.Err() == nil; i++ {
+			var job Job
+			select {
+			case j, ok := <-in:
+				if !ok {
+					break loop
+				}
+				job = j
+			case <-ctx.Done():
+				break loop
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break loop
+			}
+			var slot chan Result
+			if c.Ordered {
+				slot = make(chan Result, 1)
+				select {
+				case queue <- slot:
+				case <-ctx.Done():
+					break loop
+				}
+			}
+			wg.Add(1)
+			go func(i int, job Job, slot chan Result) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				src := job.Src
+				if src == "" {
+					src = "auto"
+				}
+				r := Result{ID: job.ID, Index: i}
+				r.Translated, r.Err = a.TranslateContext(ctx, job.Text, src, job.Dest)
+				if slot != nil {
+					slot <- r
+				} else {
+					send(r)
+				}
+			}(i, job, slot)
+		}
+		wg.Wait()
+		if queue != nil {
+			close(queue)
+		}
+		<-written
+	}()
+	return out
+}
+
+// TranslateTexts translates every text of texts through TranslateStream and
+// returns the translations in the same order. Identical texts are translated
+// once and blank texts are returned unchanged. The first failure is returned.
+//
+// Example Usage:
+//
+//	translated, err := translator.TranslateTexts(ctx, []string{"Hello", "Goodbye"}, "en", "es")
+func (a *Translator) TranslateTexts(ctx context.Context, texts []string, src, dest string, config ...StreamConfig) ([]string, error) {
+	out := make([]string, len(texts))
+	first := map[string]int{} // text to the index of the job translating it
+	var unique []string
+	for i, text := range texts {
+		out[i] = text
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if _, ok := first[text]; !ok {
+			first[text] = len(unique)
+			unique = append(unique, text)
+		}
+	}
+	if len(unique) == 0 {
+		return out, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	in := make(chan Job)
+	go func() {
+		defer close(in)
+		for _, text := range unique {
+			select {
+			case in <- Job{Text: text, Src: src, Dest: dest}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	translated := make([]string, len(unique))
+	done := 0
+	for r := range a.TranslateStream(ctx, in, config...) {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		translated[r.Index] = r.Translated.Text
+		done++
+	}
+	if done < len(unique) {
+		return nil, ctx.Err()
+	}
+	for i, text := range texts {
+		if j, ok := first[text]; ok {
+			out[i] = translated[j]
+		}
+	}
+	return out, nil
+}
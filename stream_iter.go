@@ -0,0 +1,52 @@
+//go:build go1.23
+
+package translator
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+// TranslateSeq is the iterator form of TranslateStream: it translates the jobs
+// of seq and yields a Result for each of them. Breaking out of the loop stops
+// reading jobs and abandons those in flight; seq has returned by the time the
+// loop ends.
+//
+// Example Usage:
+//
+//	for result := range translator.TranslateSeq(ctx, slices.Values(jobs), translator.StreamConfig{Ordered: true}) {
+//	  fmt.Println(result.ID, result.Translated.Text, result.Err)
+//	}
+func (a *Translator) TranslateSeq(ctx context.Context, seq iter.Seq[Job], config ...StreamConfig) iter.Seq[Result] {
+	return func(yield func(Result) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		defer cancel()
+
+		in := make(chan Job)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(in)
+			for job := range seq {
+				select {
+				case in <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		results := a.TranslateStream(ctx, in, config...)
+		for r := range results {
+			if !yield(r) {
+				cancel()
+				for range results {
+				}
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+//go:build go1.23
+
+package translator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTranslateSeq(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	trans := newTestTranslator(t, streamHandler(&inFlight, &peak))
+	jobs := []Job{{ID: "a", Text: "2 a", Dest: "es"}, {ID: "b", Text: "0 b", Dest: "es"}, {ID: "c", Text: "c", Dest: "es"}}
+
+	var ids []string
+	for r := range trans.TranslateSeq(context.Background(), slices.Values(jobs), StreamConfig{Ordered: true}) {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		ids = append(ids, r.ID)
+	}
+	if !slices.Equal(ids, []string{"a", "b", "c"}) {
+		t.Fatalf("got %v", ids)
+	}
+
+	// Breaking out waits for the jobs sequence to return.
+	var running atomic.Bool
+	endless := func(yield func(Job) bool) {
+		running.Store(true)
+		defer func() {
+			time.Sleep(20 * time.Millisecond) // cleanup of the sequence
+			running.Store(false)
+		}()
+		for i := 0; ; i++ {
+			if !yield(Job{ID: fmt.Sprint(i), Text: "0 x", Dest: "es"}) {
+				return
+			}
+		}
+	}
+	for r := range trans.TranslateSeq(context.Background(), endless) {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		break
+	}
+	if running.Load() {
+		t.Fatal("jobs sequence still running after break")
+	}
+}
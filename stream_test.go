@@ -0,0 +1,143 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// streamHandler upper-cases the query after a delay given by its first
+// character, fails for "fail" and records the peak number of requests in flight.
+func streamHandler(inFlight, peak *atomic.Int32) http.Handler {
+	var hits atomic.Int32
+	upper := upperHandler(&hits, false)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		if r.URL.Path == "/translate_a/single" {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			if q == "fail" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			if q != "" && q[0] >= '0' && q[0] <= '9' {
+				time.Sleep(time.Duration(q[0]-'0') * 10 * time.Millisecond)
+			}
+		}
+		upper.ServeHTTP(w, r)
+	})
+}
+
+func TestTranslateAsync(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	trans := newTestTranslator(t, streamHandler(&inFlight, &peak))
+
+	future := trans.TranslateAsync(context.Background(), "hello", "en", "es")
+	select {
+	case <-future.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("future not done")
+	}
+	result, err := future.Wait()
+	if err != nil || result.Text != "HELLO" {
+		t.Fatalf("got %+v, %v", result, err)
+	}
+}
+
+func TestTranslateStream(t *testing.T) {
+	texts := []string{"5 slow", "fail", "0 fast", "1 b", "2 c", "0 d", "3 e", "0 f"}
+	run := func(t *testing.T, config StreamConfig) []Result {
+		var inFlight, peak atomic.Int32
+		trans := newTestTranslator(t, streamHandler(&inFlight, &peak))
+		in := make(chan Job)
+		go func() {
+			defer close(in)
+			for i, text := range texts {
+				in <- Job{ID: fmt.Sprintf("job-%d", i), Text: text, Dest: "es"}
+			}
+		}()
+		var results []Result
+		for r := range trans.TranslateStream(context.Background(), in, config) {
+			results = append(results, r)
+		}
+		if len(results) != len(texts) {
+			t.Fatalf("got %d results, want %d", len(results), len(texts))
+		}
+		if p := peak.Load(); p > int32(config.Concurrency) {
+			t.Fatalf("%d requests in flight, want at most %d", p, config.Concurrency)
+		}
+		for _, r := range results {
+			if r.ID != fmt.Sprintf("job-%d", r.Index) {
+				t.Fatalf("result %+v does not match its job", r)
+			}
+			text := texts[r.Index]
+			if text == "fail" {
+				if r.Err == nil {
+					t.Fatalf("job %s: want an error", r.ID)
+				}
+				continue
+			}
+			if r.Err != nil || r.Translated.Text != strings.ToUpper(text) || r.Translated.Src != "auto" {
+				t.Fatalf("job %s: got %+v, %v", r.ID, r.Translated, r.Err)
+			}
+		}
+		return results
+	}
+
+	t.Run("ordered", func(t *testing.T) {
+		for i, r := range run(t, StreamConfig{Concurrency: 3, Ordered: true}) {
+			if r.Index != i {
+				t.Fatalf("result %d has index %d", i, r.Index)
+			}
+		}
+	})
+	t.Run("unordered", func(t *testing.T) {
+		results := run(t, StreamConfig{Concurrency: 3})
+		if results[0].Index == 0 {
+			t.Fatal("the slow first job was delivered first")
+		}
+	})
+}
+
+func TestTranslateStream_Cancel(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	trans := newTestTranslator(t, streamHandler(&inFlight, &peak))
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan Job) // never closed
+	out := trans.TranslateStream(ctx, in)
+	in <- Job{Text: "0 a", Dest: "es"}
+	if r := <-out; r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("unexpected result after cancel")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not closed after cancel")
+	}
+}
+
+func TestTranslateTexts(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	trans := newTestTranslator(t, streamHandler(&inFlight, &peak))
+	got, err := trans.TranslateTexts(context.Background(), []string{"1 a", " ", "0 b", "1 a"}, "en", "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1 A", " ", "0 B", "1 A"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if _, err := trans.TranslateTexts(context.Background(), []string{"0 a", "fail"}, "en", "es"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,326 @@
+// Package subtitle reads, translates and writes SRT, WebVTT and ASS/SSA
+// subtitle files.
+//
+// Parse detects the format of a file and returns its cues; WriteTo writes the
+// file back in the same format. Translate translates the cue text in batches,
+// keeping timings, cue identifiers, styling tags and line breaks:
+//
+//	f, err := subtitle.Parse(in)
+//	if err != nil {
+//	  return err
+//	}
+//	if err := subtitle.Translate(ctx, t, f, "en", "es"); err != nil {
+//	  return err
+//	}
+//	_, err = f.WriteTo(out)
+package subtitle
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a subtitle file format.
+type Format string
+
+// Supported formats.
+const (
+	SRT    Format = "srt"
+	WebVTT Format = "vtt"
+	ASS    Format = "ass" // also SSA
+)
+
+// Cue is a subtitle shown between Start and End.
+type Cue struct {
+	ID       string // SRT sequence number or WebVTT cue identifier
+	Start    time.Duration
+	End      time.Duration
+	Settings string // WebVTT cue settings or SRT coordinates following the timings
+	Text     string // text with its styling tags, lines separated by "\n"
+
+	fields []string  // ASS event fields before the text, timings included
+	read   cueTiming // timings as read, written back unless changed
+}
+
+// cueTiming holds the timings of a cue as they were read.
+type cueTiming struct {
+	line       string // SRT or WebVTT timing line
+	start, end time.Duration
+	settings   string
+	short      bool // WebVTT timestamps without hours
+}
+
+// File is a parsed subtitle file. The cues may be edited in place; everything
+// else in the file is written back as it was read.
+type File struct {
+	Format Format
+	Cues   []*Cue
+
+	blocks []block  // the file in order: verbatim text and cues
+	bom    bool     // the file started with a byte order mark
+	crlf   bool     // lines ended with "\r\n"
+	noEOL  bool     // the last line had no line ending
+	format []string // ASS event field names, lower case
+}
+
+// block is a piece of a file: verbatim text or, when cue is set, a cue.
+type block struct {
+	text string
+	cue  *Cue
+}
+
+// Parse reads a subtitle file, detecting its format from its content.
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{bom: bytes.HasPrefix(data, []byte("\ufeff")), crlf: bytes.Contains(data, []byte("\r\n"))}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "WEBVTT"):
+		f.Format = WebVTT
+		err = f.parseBlocks(text)
+	case strings.HasPrefix(trimmed, "[Script Info]") || strings.Contains(text, "\n[Events]"):
+		f.Format = ASS
+		err = f.parseASS(text)
+	default:
+		f.Format = SRT
+		err = f.parseBlocks(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parseBlocks parses SRT and WebVTT, whose cues are blocks separated by blank lines.
+func (f *File) parseBlocks(text string) error {
+	f.noEOL = !strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "" {
+			f.blocks = append(f.blocks, block{text: lines[i] + "\n"})
+			i++
+			continue
+		}
+		n := i
+		for n < len(lines) && strings.TrimSpace(lines[n]) != "" {
+			n++
+		}
+		cue, err := f.parseCue(lines[i:n], i)
+		if err != nil {
+			return err
+		}
+		if cue == nil {
+			f.blocks = append(f.blocks, block{text: strings.Join(lines[i:n], "\n") + "\n"})
+		} else {
+			f.Cues = append(f.Cues, cue)
+			f.blocks = append(f.blocks, block{cue: cue})
+		}
+		i = n
+	}
+	return nil
+}
+
+// parseCue parses an SRT or WebVTT block, returning nil for WebVTT blocks that
+// are not cues, such as the header, notes, styles and regions.
+func (f *File) parseCue(lines []string, lineNo int) (*Cue, error) {
+	timing := 0
+	if !strings.Contains(lines[0], "-->") {
+		if len(lines) < 2 || !strings.Contains(lines[1], "-->") || (f.Format == WebVTT && isVTTBlock(lines[0])) {
+			if f.Format == WebVTT {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("subtitle: line %d: missing cue timings", lineNo+1)
+		}
+		timing = 1
+	}
+	cue := &Cue{Text: strings.Join(lines[timing+1:], "\n")}
+	if timing == 1 {
+		cue.ID = lines[0]
+	}
+	start, rest, _ := strings.Cut(lines[timing], "-->")
+	end, settings, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	var err error
+	if cue.Start, err = parseTimestamp(strings.TrimSpace(start)); err == nil {
+		cue.End, err = parseTimestamp(end)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("subtitle: line %d: %v", lineNo+timing+1, err)
+	}
+	cue.Settings = strings.TrimSpace(settings)
+	cue.read = cueTiming{line: lines[timing], start: cue.Start, end: cue.End, settings: cue.Settings,
+		short: strings.Count(strings.TrimSpace(start), ":") == 1}
+	return cue, nil
+}
+
+func isVTTBlock(line string) bool {
+	for _, prefix := range []string{"WEBVTT", "NOTE", "STYLE", "REGION"} {
+		if line == prefix || strings.HasPrefix(line, prefix+" ") || strings.HasPrefix(line, prefix+"\t") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimestamp parses SRT, WebVTT and ASS timestamps: [h:]mm:ss followed by
+// a comma or dot and the fraction of a second.
+func parseTimestamp(s string) (time.Duration, error) {
+	fields := strings.Split(strings.Replace(s, ",", ".", 1), ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	minutes := 0
+	for _, field := range fields[:len(fields)-1] {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		minutes = minutes*60 + n
+	}
+	secs, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+	if err != nil || secs < 0 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return time.Duration(minutes)*time.Minute + time.Duration(secs*1000+0.5)*time.Millisecond, nil
+}
+
+// parseASS parses ASS and SSA files, whose cues are the Dialogue lines of the
+// [Events] section.
+func (f *File) parseASS(text string) error {
+	section := ""
+	for i, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		content := strings.TrimRight(line, "\n")
+		trimmed := strings.TrimSpace(content)
+		switch {
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section = strings.ToLower(trimmed)
+		case section == "[events]" && strings.HasPrefix(trimmed, "Format:"):
+			f.format = nil
+			for _, name := range strings.Split(strings.TrimPrefix(trimmed, "Format:"), ",") {
+				f.format = append(f.format, strings.ToLower(strings.TrimSpace(name)))
+			}
+		case section == "[events]" && strings.HasPrefix(content, "Dialogue:"):
+			if len(f.format) == 0 || f.format[len(f.format)-1] != "text" {
+				return fmt.Errorf("subtitle: line %d: dialogue before a Format line ending with Text", i+1)
+			}
+			fields := strings.SplitN(strings.TrimPrefix(content, "Dialogue:"), ",", len(f.format))
+			if len(fields) != len(f.format) {
+				return fmt.Errorf("subtitle: line %d: expected %d fields", i+1, len(f.format))
+			}
+			cue := &Cue{fields: fields[:len(fields)-1], Text: strings.ReplaceAll(fields[len(fields)-1], `\N`, "\n")}
+			var err error
+			for j, name := range f.format {
+				switch name {
+				case "start":
+					cue.Start, err = parseTimestamp(strings.TrimSpace(fields[j]))
+				case "end":
+					cue.End, err = parseTimestamp(strings.TrimSpace(fields[j]))
+				}
+				if err != nil {
+					return fmt.Errorf("subtitle: line %d: %v", i+1, err)
+				}
+			}
+			cue.read = cueTiming{start: cue.Start, end: cue.End}
+			f.Cues = append(f.Cues, cue)
+			f.blocks = append(f.blocks, block{cue: cue})
+			if strings.HasSuffix(line, "\n") {
+				f.blocks = append(f.blocks, block{text: "\n"})
+			}
+			continue
+		}
+		f.blocks = append(f.blocks, block{text: line})
+	}
+	return nil
+}
+
+// WriteTo writes the file in its format.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, blk := range f.blocks {
+		if blk.cue == nil {
+			b.WriteString(blk.text)
+			continue
+		}
+		cue := blk.cue
+		switch f.Format {
+		case ASS:
+			b.WriteString("Dialogue:")
+			for j, field := range cue.fields {
+				switch {
+				case f.format[j] == "start" && cue.Start != cue.read.start:
+					field = leadingSpace(field) + formatASSTime(cue.Start)
+				case f.format[j] == "end" && cue.End != cue.read.end:
+					field = leadingSpace(field) + formatASSTime(cue.End)
+				}
+				b.WriteString(field + ",")
+			}
+			b.WriteString(strings.ReplaceAll(cue.Text, "\n", `\N`))
+		default:
+			if cue.ID != "" {
+				b.WriteString(cue.ID + "\n")
+			}
+			if r := cue.read; r.line != "" && cue.Start == r.start && cue.End == r.end && cue.Settings == r.settings {
+				b.WriteString(r.line)
+			} else {
+				sep := "."
+				if f.Format == SRT {
+					sep = ","
+				}
+				b.WriteString(formatTime(cue.Start, sep, r.short) + " --> " + formatTime(cue.End, sep, r.short))
+				if cue.Settings != "" {
+					b.WriteString(" " + cue.Settings)
+				}
+			}
+			b.WriteString("\n" + cue.Text + "\n")
+		}
+	}
+	out := b.String()
+	if f.bom {
+		out = "\ufeff" + out
+	}
+	if f.noEOL {
+		out = strings.TrimSuffix(out, "\n")
+	}
+	if f.crlf {
+		out = strings.ReplaceAll(out, "\n", "\r\n")
+	}
+	bw := bufio.NewWriter(w)
+	n, err := bw.WriteString(out)
+	if err == nil {
+		err = bw.Flush()
+	}
+	return int64(n), err
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " "))]
+}
+
+// formatTime formats d as hh:mm:ss followed by sep and milliseconds, or as
+// mm:ss when short is set and d is under an hour.
+func formatTime(d time.Duration, sep string, short bool) string {
+	ms := d.Milliseconds()
+	if short && d < time.Hour {
+		return fmt.Sprintf("%02d:%02d%s%03d", ms/60000, ms/1000%60, sep, ms%1000)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// formatASSTime formats d as h:mm:ss.cc.
+func formatASSTime(d time.Duration) string {
+	cs := (d.Milliseconds() + 5) / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
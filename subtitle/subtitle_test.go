@@ -0,0 +1,134 @@
+package subtitle
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const srtFile = "1\r\n00:00:01,000 --> 00:00:03,500\r\n<i>Hello there,</i>\r\nhow are you?\r\n\r\n2\r\n00:01:02,250 --> 00:01:04,000 X1:10 X2:20\r\n{\\an8}Fine.\r\n"
+
+const vttFile = `WEBVTT - sample
+
+NOTE a note
+spanning lines
+
+STYLE
+::cue { color: yellow }
+
+intro
+00:01.000 --> 00:03.500 align:start position:10%
+<v Anna>Hello <b>there</b></v>
+
+01:00:00.000 --> 01:00:01.000
+<c.loud>Bye</c>
+`
+
+const assFile = `[Script Info]
+Title: Sample
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize
+Style: Default,Arial,20
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:03.50,Default,,0,0,0,,{\i1}Hello, there{\i0}\Nhow are you?
+Comment: 0,0:00:02.00,0:00:03.00,Default,,0,0,0,,not shown
+Dialogue: 0,1:02:03.45,1:02:04.00,Default,Anna,0,0,0,,Fine, thanks.
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		format Format
+		cues   []Cue
+	}{
+		{"srt", srtFile, SRT, []Cue{
+			{ID: "1", Start: time.Second, End: 3500 * time.Millisecond, Text: "<i>Hello there,</i>\nhow are you?"},
+			{ID: "2", Start: time.Minute + 2250*time.Millisecond, End: time.Minute + 4*time.Second, Settings: "X1:10 X2:20", Text: "{\\an8}Fine."},
+		}},
+		{"vtt", vttFile, WebVTT, []Cue{
+			{ID: "intro", Start: time.Second, End: 3500 * time.Millisecond, Settings: "align:start position:10%", Text: "<v Anna>Hello <b>there</b></v>"},
+			{Start: time.Hour, End: time.Hour + time.Second, Text: "<c.loud>Bye</c>"},
+		}},
+		{"ass", assFile, ASS, []Cue{
+			{Start: time.Second, End: 3500 * time.Millisecond, Text: "{\\i1}Hello, there{\\i0}\nhow are you?"},
+			{Start: time.Hour + 2*time.Minute + 3450*time.Millisecond, End: time.Hour + 2*time.Minute + 4*time.Second, Text: "Fine, thanks."},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Format != tt.format || len(f.Cues) != len(tt.cues) {
+				t.Fatalf("got format %s with %d cues", f.Format, len(f.Cues))
+			}
+			for i, want := range tt.cues {
+				got := *f.Cues[i]
+				got.fields, got.read = nil, cueTiming{}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("cue %d: got %+v, want %+v", i, got, want)
+				}
+			}
+
+			var out bytes.Buffer
+			if _, err := f.WriteTo(&out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.in {
+				t.Fatalf("round trip:\n%q\nwant\n%q", out.String(), tt.in)
+			}
+		})
+	}
+}
+
+func TestWriteTo_RoundTrip(t *testing.T) {
+	for _, in := range []string{srtFile, vttFile, assFile} {
+		in = "\ufeff" + strings.Replace(in, " --> ", "  -->  ", 1)
+		f, err := Parse(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if _, err := f.WriteTo(&out); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != in {
+			t.Fatalf("round trip:\n%q\nwant\n%q", out.String(), in)
+		}
+	}
+
+	// Changed WebVTT timings keep the form they were read in.
+	f, err := Parse(strings.NewReader(vttFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Cues[0].Start += time.Second
+	f.Cues[1].End += time.Second
+	var out bytes.Buffer
+	f.WriteTo(&out)
+	for _, want := range []string{"00:02.000 --> 00:03.500 align:start position:10%\n", "01:00:00.000 --> 01:00:02.000\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("got\n%s\nwant a line %q", out.String(), want)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, in := range []string{
+		"1\nnot a timing\ntext\n",
+		"1\n00:00:01,000 --> soon\ntext\n",
+		"[Events]\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,text\n",
+	} {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("Parse(%q) succeeded", in)
+		}
+	}
+}
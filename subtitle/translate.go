@@ -0,0 +1,187 @@
+package subtitle
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+const defaultBatchSize = 1000
+
+// tags matches the styling of cue text, HTML-like tags and ASS override blocks
+// and escapes, and the line break marker used while translating.
+var tags = regexp.MustCompile(`<[^<>]*>|\{[^{}]*\}|\\[nh]`)
+
+// lineBreak stands for the line breaks of a cue while it is translated, as the
+// cues of a batch are separated by line breaks.
+const lineBreak = "<br>"
+
+var lineBreaks = regexp.MustCompile(`\s*<br>\s*`)
+
+// sentenceEnd matches text ending a sentence, ignoring trailing tags.
+var sentenceEnd = regexp.MustCompile(`[.!?…。！？♪"»”)\]-]\s*(?:(?:<[^<>]*>|\{[^{}]*\})\s*)*$`)
+
+// Options tunes how Translate batches and merges cues.
+type Options struct {
+	// BatchSize is the number of characters of cue text sent per request. Defaults to 1000.
+	BatchSize int
+	// MergeSentences translates sentences that run over several cues as a
+	// whole, for better context, and spreads the translation over the cues by
+	// length. The line breaks within those cues are lost.
+	MergeSentences bool
+	// MaxMerge is the number of cues merged at most. Defaults to 4.
+	MaxMerge int
+}
+
+// unit is a text translated as a whole: one cue, or several with MergeSentences.
+type unit struct {
+	cues []*Cue
+	text string
+}
+
+// Translate translates the text of the cues of f from src to dest in place.
+//
+// Cues are sent in batches of about opts.BatchSize characters, one cue per
+// line. Styling tags are kept next to the words around them, and the line
+// breaks within a cue are kept. Batches whose translation does not split back
+// into as many cues are translated cue by cue instead.
+func Translate(ctx context.Context, t translator.ProtectedTranslator, f *File, src, dest string, opts ...Options) error {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = defaultBatchSize
+	}
+	if o.MaxMerge <= 0 {
+		o.MaxMerge = 4
+	}
+
+	units := split(f.Cues, o)
+	var batches []string
+	var members [][]*unit
+	size := 0
+	for _, u := range units {
+		n := utf8.RuneCountInString(u.text)
+		if len(batches) == 0 || size+n > o.BatchSize {
+			batches = append(batches, "")
+			members = append(members, nil)
+			size = 0
+		}
+		last := len(batches) - 1
+		if members[last] != nil {
+			batches[last] += "\n"
+		}
+		batches[last] += u.text
+		members[last] = append(members[last], u)
+		size += n + 1
+	}
+
+	translated, err := t.TranslateProtected(ctx, batches, tags, src, dest)
+	if err != nil {
+		return err
+	}
+	var retry []*unit
+	for i, batch := range members {
+		lines := strings.Split(strings.TrimSpace(translated[i]), "\n")
+		if len(lines) != len(batch) {
+			retry = append(retry, batch...)
+			continue
+		}
+		for j, u := range batch {
+			u.apply(lines[j])
+		}
+	}
+	if len(retry) == 0 {
+		return nil
+	}
+
+	texts := make([]string, len(retry))
+	for i, u := range retry {
+		texts[i] = u.text
+	}
+	if translated, err = t.TranslateProtected(ctx, texts, tags, src, dest); err != nil {
+		return err
+	}
+	for i, u := range retry {
+		u.apply(translated[i])
+	}
+	return nil
+}
+
+// split groups the cues with text into units.
+func split(cues []*Cue, o Options) []*unit {
+	var units []*unit
+	var open *unit // unit whose last cue does not end a sentence
+	for _, cue := range cues {
+		if strings.TrimSpace(cue.Text) == "" {
+			open = nil
+			continue
+		}
+		if !o.MergeSentences {
+			units = append(units, &unit{cues: []*Cue{cue}, text: strings.ReplaceAll(strings.TrimSpace(cue.Text), "\n", lineBreak)})
+			continue
+		}
+		text := strings.Join(strings.Fields(cue.Text), " ")
+		if open != nil {
+			open.cues = append(open.cues, cue)
+			open.text += " " + text
+		} else {
+			open = &unit{cues: []*Cue{cue}, text: text}
+			units = append(units, open)
+		}
+		if sentenceEnd.MatchString(text) || len(open.cues) >= o.MaxMerge {
+			open = nil
+		}
+	}
+	if !o.MergeSentences {
+		return units
+	}
+	// Single cues keep their line breaks.
+	for _, u := range units {
+		if len(u.cues) == 1 {
+			u.text = strings.ReplaceAll(strings.TrimSpace(u.cues[0].Text), "\n", lineBreak)
+		}
+	}
+	return units
+}
+
+// apply sets the cue text of u from its translation.
+func (u *unit) apply(translated string) {
+	translated = strings.TrimSpace(translated)
+	if len(u.cues) == 1 {
+		u.cues[0].Text = lineBreaks.ReplaceAllString(translated, "\n")
+		return
+	}
+
+	// Spread the words over the cues in proportion to their original length.
+	total := 0
+	lengths := make([]int, len(u.cues))
+	for i, cue := range u.cues {
+		lengths[i] = utf8.RuneCountInString(cue.Text)
+		total += lengths[i]
+	}
+	words := strings.Fields(lineBreaks.ReplaceAllString(translated, " "))
+	spaced := len(words) > 1
+	if !spaced {
+		// Scripts written without spaces are split by characters.
+		words = strings.Split(translated, "")
+	}
+	done, start := 0, 0
+	for i, cue := range u.cues {
+		done += lengths[i]
+		end := (len(words)*done + total/2) / total
+		if i == len(u.cues)-1 {
+			end = len(words)
+		}
+		sep := ""
+		if spaced {
+			sep = " "
+		}
+		cue.Text = strings.Join(words[start:end], sep)
+		start = end
+	}
+}
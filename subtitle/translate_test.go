@@ -0,0 +1,65 @@
+package subtitle
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lcapuano-app/go-googletrans/internal/translatortest"
+)
+
+// joinLines merges the lines of multi-line texts, as the service sometimes does.
+func joinLines(_, translated string) string {
+	return strings.ReplaceAll(translated, "\n", " ")
+}
+
+func TestTranslate(t *testing.T) {
+	for _, join := range []bool{false, true} {
+		f, err := Parse(strings.NewReader(srtFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fake := &translatortest.Fake{}
+		if join {
+			fake.Edit = joinLines
+		}
+		if err := Translate(context.Background(), fake, f, "en", "es"); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := f.Cues[0].Text, "<i>HELLO THERE,</i>\nHOW ARE YOU?"; got != want {
+			t.Fatalf("join %v: got %q, want %q", join, got, want)
+		}
+		if got, want := f.Cues[1].Text, "{\\an8}FINE."; got != want {
+			t.Fatalf("join %v: got %q, want %q", join, got, want)
+		}
+		wantCalls := 1
+		if join {
+			wantCalls = 2 // the batch, then cue by cue
+		}
+		if len(fake.Calls) != wantCalls || len(fake.Calls[0]) != 1 {
+			t.Fatalf("join %v: calls %q", join, fake.Calls)
+		}
+	}
+}
+
+func TestTranslate_MergeSentences(t *testing.T) {
+	in := "1\n00:00:01,000 --> 00:00:02,000\nThis sentence runs\n\n" +
+		"2\n00:00:02,000 --> 00:00:03,000\nover two cues.\n\n" +
+		"3\n00:00:03,000 --> 00:00:04,000\nShort.\nTwo lines.\n"
+	f, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &translatortest.Fake{}
+	if err := Translate(context.Background(), fake, f, "en", "es", Options{MergeSentences: true, BatchSize: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Calls[0]; len(got) != 2 || got[0] != "This sentence runs over two cues." {
+		t.Fatalf("batches %q", got)
+	}
+	for i, want := range []string{"THIS SENTENCE RUNS", "OVER TWO CUES.", "SHORT.\nTWO LINES."} {
+		if f.Cues[i].Text != want {
+			t.Errorf("cue %d: got %q, want %q", i, f.Cues[i].Text, want)
+		}
+	}
+}
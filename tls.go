@@ -0,0 +1,19 @@
+package translator
+
+import (
+	"crypto/tls"
+)
+
+// tlsConfig builds the client TLS configuration from c. Certificates are
+// verified unless c.InsecureSkipVerify is set.
+func (a *Translator) tlsConfig(c Config) *tls.Config {
+	if c.InsecureSkipVerify {
+		a.up.logger.Warn("TLS certificate verification is disabled; connections to the service can be intercepted")
+	}
+	return &tls.Config{
+		RootCAs:            c.RootCAs,
+		Certificates:       c.Certificates,
+		MinVersion:         c.MinTLSVersion,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+}
@@ -0,0 +1,68 @@
+package translator
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTLSConfig(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/translate_a/single" {
+			w.Write([]byte("<html>homepage</html>"))
+			return
+		}
+		w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+	})
+	srv := httptest.NewUnstartedServer(h)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MaxVersion: tls.VersionTLS12}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	// The server certificate doubles as client certificate.
+	clientCert := srv.TLS.Certificates[0]
+	host := srv.Listener.Addr().String()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	trans := New(Config{ServiceUrls: []string{host}, Certificates: []tls.Certificate{clientCert}, Logger: logger})
+	_, err := trans.Translate("hello", "en", "es")
+	var certErr *tls.CertificateVerificationError
+	if !errors.As(err, &certErr) {
+		t.Fatalf("unknown authority: got %v, want a certificate verification error", err)
+	}
+	if strings.Contains(buf.String(), "verification is disabled") {
+		t.Errorf("warning logged without InsecureSkipVerify: %s", buf.String())
+	}
+
+	trans = New(Config{ServiceUrls: []string{host}, Certificates: []tls.Certificate{clientCert}, InsecureSkipVerify: true, Logger: logger})
+	if _, err := trans.Translate("hello", "en", "es"); err != nil {
+		t.Fatalf("insecure: %v", err)
+	}
+	if !strings.Contains(buf.String(), "verification is disabled") {
+		t.Errorf("insecure mode did not log a warning: %s", buf.String())
+	}
+
+	pool := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	trans = New(Config{ServiceUrls: []string{host}, RootCAs: pool, Certificates: []tls.Certificate{clientCert}})
+	if _, err := trans.Translate("hello", "en", "es"); err != nil {
+		t.Fatalf("custom roots: %v", err)
+	}
+
+	trans = New(Config{ServiceUrls: []string{host}, RootCAs: pool})
+	if _, err := trans.Translate("hello", "en", "es"); err == nil {
+		t.Fatal("missing client certificate: expected an error")
+	}
+
+	trans = New(Config{ServiceUrls: []string{host}, RootCAs: pool, Certificates: []tls.Certificate{clientCert}, MinTLSVersion: tls.VersionTLS13})
+	if _, err := trans.Translate("hello", "en", "es"); err == nil {
+		t.Fatal("MinTLSVersion above the server maximum: expected an error")
+	}
+}
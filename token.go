@@ -1,22 +1,29 @@
 package translator
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"math"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 var ReTkk = regexp.MustCompile(`tkk:'(.+?)'`)
 
 type tokenAcquirer struct {
+	mu     sync.Mutex
 	tkk    string
 	host   string
 	client *http.Client
+	up     *upstream
 }
 
 func Token(host string, client *http.Client) *tokenAcquirer {
@@ -28,40 +35,71 @@ func Token(host string, client *http.Client) *tokenAcquirer {
 		tkk:    "0",
 		host:   host,
 		client: client,
+		up:     newUpstream(Config{}),
 	}
 }
 
-func (a *tokenAcquirer) do(text string) (string, error) {
-	err := a.update()
+func (a *tokenAcquirer) do(ctx context.Context, text string) (string, error) {
+	err := a.update(ctx)
 	if err != nil {
 		return "", err
 	}
+	a.mu.Lock()
 	tk := a.acquire(text)
+	a.mu.Unlock()
 	return tk, nil
 }
 
-func (a *tokenAcquirer) update() error {
+func (a *tokenAcquirer) update(ctx context.Context) (err error) {
 	now := int(math.Floor(float64(time.Now().UnixNano()) / 1000000.00 / 3600000.00))
 
-	tkk, _ := strconv.Atoi(strings.Split(a.tkk, ".")[0])
-	if a.tkk != "" && tkk == now {
+	// tkk is shared by every request of the Translator, which may run
+	// concurrently, so it is only touched under the lock.
+	a.mu.Lock()
+	current := a.tkk
+	a.mu.Unlock()
+
+	tkk, _ := strconv.Atoi(strings.Split(current, ".")[0])
+	cached := current != "" && tkk == now
+
+	ctx, span := a.up.tracer.Start(ctx, "translator.tkk_refresh", trace.WithAttributes(
+		attrHost.String(strings.TrimPrefix(a.host, "https://")),
+		attrCacheHit.Bool(cached),
+	))
+	defer func() { endSpan(span, err) }()
+	if cached {
 		return nil
 	}
 
-	resp, err := a.client.Get(a.host)
+	req, err := http.NewRequestWithContext(ctx, "GET", a.host, nil)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	body, err := a.up.send(a.client, req, upstreamCall{op: OpTokenRefresh})
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		// The gtx client does not need a TKK, so an unexpected status is not fatal.
+		a.up.logger.LogAttrs(ctx, slog.LevelInfo, "tkk refresh skipped", slog.String("host", req.URL.Host), slog.Int("status", httpErr.StatusCode))
+		return nil
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		// The circuit opened on the statuses ignored above: keep the current TKK.
+		a.up.logger.LogAttrs(ctx, slog.LevelDebug, "tkk refresh skipped", slog.String("host", req.URL.Host), slog.Any("error", err))
+		return nil
+	}
 	if err != nil {
+		// A blocked homepage has no TKK, and the translate call would be blocked as well.
 		return err
 	}
 	rawTkk := ReTkk.FindStringSubmatch(string(body))
 	if len(rawTkk) > 0 {
+		a.mu.Lock()
 		a.tkk = rawTkk[1]
+		a.mu.Unlock()
+		a.up.logger.LogAttrs(ctx, slog.LevelDebug, "tkk refreshed", slog.String("host", req.URL.Host))
 		return nil
 	}
+	a.up.logger.LogAttrs(ctx, slog.LevelDebug, "tkk not found on homepage", slog.String("host", req.URL.Host))
 	return nil
 }
 
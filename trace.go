@@ -0,0 +1,41 @@
+package translator
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName identifies the spans created by this package.
+const tracerName = "github.com/lcapuano-app/go-googletrans"
+
+// Span attributes set by the Translator.
+const (
+	attrSrc        = attribute.Key("translator.src")
+	attrDest       = attribute.Key("translator.dest")
+	attrTextLength = attribute.Key("translator.text_length")
+	attrOperation  = attribute.Key("translator.operation")
+	attrRetry      = attribute.Key("translator.retry")
+	attrCacheHit   = attribute.Key("translator.tkk.cache_hit")
+	attrHost       = attribute.Key("server.address")
+	attrStatusCode = attribute.Key("http.response.status_code")
+)
+
+// newTracer returns the tracer of provider, or a tracer that records nothing
+// when tracing is not configured.
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
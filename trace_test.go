@@ -0,0 +1,53 @@
+package translator
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTranslateContext_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	trans := newTestTranslator(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/translate_a/single" {
+			w.Write([]byte(`{"sentences":[{"trans":"hola","orig":"hello"}]}`))
+		}
+	}), Config{TracerProvider: provider})
+
+	if _, err := trans.TranslateContext(context.Background(), "hello", "EN", "es"); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	names := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		names[s.Name()] = s
+	}
+	parent, ok := names["translator.Translate"]
+	if !ok || len(spans) != 5 {
+		t.Fatalf("unexpected spans %v", names)
+	}
+	for _, name := range []string{"translator.tkk_refresh", "translator.http_attempt", "translator.parse_response"} {
+		s, ok := names[name]
+		if !ok || s.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+			t.Errorf("span %s missing or not in the call's trace", name)
+		}
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range parent.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["translator.src"] != "en" || attrs["translator.dest"] != "es" || attrs["translator.text_length"] != "5" {
+		t.Errorf("unexpected parent attributes %v", attrs)
+	}
+	for _, kv := range names["translator.tkk_refresh"].Attributes() {
+		if kv.Key == attrCacheHit && kv.Value.AsBool() {
+			t.Errorf("first token refresh reported as cache hit")
+		}
+	}
+}
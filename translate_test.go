@@ -1,6 +1,9 @@
 package translator
 
 import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -45,3 +48,21 @@ func TestTranslator_DetectLanguage(t *testing.T) {
 		t.Fatalf("confidence %f should be over 0.5", result.Confidence)
 	}
 }
+
+// newTestTranslator returns a Translator whose requests go to a local TLS
+// server running h, so that tests do not depend on Google. ServiceUrls of the
+// optional config is replaced by the address of that server.
+func newTestTranslator(t *testing.T, h http.Handler, config ...Config) *Translator {
+	srv := httptest.NewTLSServer(h)
+	t.Cleanup(srv.Close)
+	var c Config
+	if len(config) > 0 {
+		c = config[0]
+	}
+	c.ServiceUrls = []string{srv.Listener.Addr().String()}
+	if c.RootCAs == nil && !c.InsecureSkipVerify {
+		c.RootCAs = x509.NewCertPool()
+		c.RootCAs.AddCert(srv.Certificate())
+	}
+	return New(c)
+}
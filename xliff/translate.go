@@ -0,0 +1,164 @@
+package xliff
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+
+	translator "github.com/lcapuano-app/go-googletrans"
+)
+
+// inline matches the inline elements and references of source text, kept as
+// they are: tags such as <x/>, <g>, <ph>, <pc> and <mrk>, character
+// references and entities.
+var inline = regexp.MustCompile(`<[^<>]+>|&(?:#[0-9]+|#x[0-9a-fA-F]+|[\w.-]+);`)
+
+// entity matches character references and entities, which may be defined
+// outside the document.
+var entity = regexp.MustCompile(`&(?:#[0-9]+|#x[0-9a-fA-F]+|[\w.-]+);`)
+
+// Options selects the units Translate fills in and the state it leaves them in.
+type Options struct {
+	// States are the states of the units translated. Defaults to "new" and
+	// "needs-translation" in XLIFF 1.2 and "initial" in XLIFF 2.0. A unit
+	// without a state is "new" or "initial" when its target is empty and
+	// "translated" otherwise.
+	States []string
+	// State is the state given to the units translated. Defaults to
+	// "needs-review-translation" in XLIFF 1.2 and "translated" in XLIFF 2.0.
+	State string
+	// StateQualifier is the state-qualifier, or the subState in XLIFF 2.0,
+	// given to the units translated. Defaults to "mt-suggestion" in XLIFF 1.2
+	// and "mt:suggestion" in XLIFF 2.0.
+	StateQualifier string
+}
+
+// Translate machine-translates the source of the units of doc in the chosen
+// states into their target and returns how many were translated. Units marked
+// translate="no" are skipped. Inline elements are kept next to the words
+// around them; when their translation would not be well-formed, the text
+// between them is translated piece by piece instead. The target language of
+// the document is set to dest when missing.
+func Translate(ctx context.Context, t translator.ProtectedTranslator, doc *Document, src, dest string, opts ...Options) (int, error) {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	initial := "new"
+	if doc.v2() {
+		initial = "initial"
+	}
+	if o.States == nil {
+		o.States = []string{initial, "needs-translation"}
+	}
+	if o.State == "" {
+		o.State = "needs-review-translation"
+		if doc.v2() {
+			o.State = "translated"
+		}
+	}
+	if o.StateQualifier == "" {
+		o.StateQualifier = "mt-suggestion"
+		if doc.v2() {
+			o.StateQualifier = "mt:suggestion"
+		}
+	}
+	if doc.TargetLang == "" {
+		doc.TargetLang = dest
+	}
+
+	var units []*Unit
+	var texts []string
+	for _, u := range doc.Units {
+		state := u.State
+		if state == "" {
+			state = initial
+			if strings.TrimSpace(u.Target) != "" {
+				state = "translated"
+			}
+		}
+		if !u.Translate || !contains(o.States, state) || strings.TrimSpace(inline.ReplaceAllString(u.Source, "")) == "" {
+			continue
+		}
+		units = append(units, u)
+		texts = append(texts, u.Source)
+	}
+	if len(units) == 0 {
+		return 0, nil
+	}
+	translated, err := t.TranslateProtected(ctx, texts, inline, src, dest)
+	if err != nil {
+		return 0, err
+	}
+
+	// Inline elements moved out of their nesting are put back in place and
+	// the text between them translated on its own.
+	var retry []int
+	var pieces []string
+	for i, u := range units {
+		if wellFormed(translated[i]) {
+			continue
+		}
+		retry = append(retry, i)
+		pieces = append(pieces, inline.Split(u.Source, -1)...)
+	}
+	if len(retry) > 0 {
+		out, err := t.TranslateProtected(ctx, pieces, inline, src, dest)
+		if err != nil {
+			return 0, err
+		}
+		for _, i := range retry {
+			source := units[i].Source
+			tags := inline.FindAllString(source, -1)
+			var b strings.Builder
+			for j, tag := range tags {
+				b.WriteString(out[j] + tag)
+			}
+			b.WriteString(out[len(tags)])
+			out = out[len(tags)+1:]
+			translated[i] = b.String()
+		}
+	}
+
+	for i, u := range units {
+		u.Target = escapeText(translated[i])
+		u.State, u.StateQualifier = o.State, o.StateQualifier
+	}
+	return len(units), nil
+}
+
+// wellFormed reports whether the inline elements of s are properly nested.
+func wellFormed(s string) bool {
+	s = entity.ReplaceAllString(escapeText(s), "")
+	dec := xml.NewDecoder(strings.NewReader("<t>" + s + "</t>"))
+	for {
+		if _, err := dec.Token(); err != nil {
+			return err == io.EOF
+		}
+	}
+}
+
+// escapeText escapes the markup characters the service may have added to the
+// text between inline elements and references.
+func escapeText(s string) string {
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;")
+	var b strings.Builder
+	last := 0
+	for _, m := range inline.FindAllStringIndex(s, -1) {
+		b.WriteString(escaper.Replace(s[last:m[0]]) + s[m[0]:m[1]])
+		last = m[1]
+	}
+	b.WriteString(escaper.Replace(s[last:]))
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package xliff
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lcapuano-app/go-googletrans/internal/translatortest"
+)
+
+var closing = regexp.MustCompile(`</[^<>]+>`)
+
+// misnest moves the closing tags of a translation to its start, as a
+// translation reordering words around an element could.
+func misnest(_, translated string) string {
+	return strings.Join(closing.FindAllString(translated, -1), "") + closing.ReplaceAllString(translated, "")
+}
+
+func TestTranslate12(t *testing.T) {
+	doc, err := Parse(strings.NewReader(xliff12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Translate(context.Background(), &translatortest.Fake{}, doc, "en", "fr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("translated %d units, want 1", n)
+	}
+	var b strings.Builder
+	doc.WriteTo(&b)
+	want := strings.NewReplacer(
+		`original="app">`, `original="app" target-language="fr">`,
+		`<g id="1">world</g>!</source>`, `<g id="1">world</g>!</source>`+"\n        "+
+			`<target state="needs-review-translation" state-qualifier="mt-suggestion">HELLO <g id="1">WORLD</g>!</target>`,
+	).Replace(xliff12)
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestTranslate20(t *testing.T) {
+	doc, err := Parse(strings.NewReader(xliff20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &translatortest.Fake{}
+	n, err := Translate(context.Background(), fake, doc, "en", "de", Options{States: []string{"initial", "translated"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("translated %d units, want 2", n)
+	}
+	var b strings.Builder
+	doc.WriteTo(&b)
+	want := strings.NewReplacer(
+		`<segment id="s1" state="initial">`, `<segment id="s1" state="translated" subState="mt:suggestion">`,
+		`<ph id="2"/></source>`, `<ph id="2"/></source>`+"\n        "+`<target>CLICK <pc id="1">HERE</pc> <ph id="2"/></target>`,
+		`<segment id="s2">`, `<segment id="s2" state="translated" subState="mt:suggestion">`,
+		`<target>Fertig</target>`, `<target>DONE</target>`,
+	).Replace(xliff20)
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestTranslate_Misnested(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`<xliff version="1.2"><file source-language="en"><body>` +
+		`<trans-unit id="a"><source>Read <g id="1">the &lt;b&gt; docs</g> &amp; more</source><target></target></trans-unit>` +
+		`</body></file></xliff>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &translatortest.Fake{Edit: misnest}
+	if _, err := Translate(context.Background(), fake, doc, "en", "fr"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.Units[0].Target, `READ <g id="1">THE &lt;B&gt; DOCS</g> &amp; MORE`; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if len(fake.Calls) != 2 || len(fake.Calls[1]) != 6 {
+		t.Fatalf("got calls %q", fake.Calls)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	if got, want := escapeText(`a < b & <x id="1"/> &amp; "c"`), `a &lt; b &amp; <x id="1"/> &amp; "c"`; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,309 @@
+// Package xliff reads, machine-translates and writes XLIFF 1.2 and 2.0
+// documents.
+//
+// Parse finds the translation units of a document; Translate fills in their
+// targets; WriteTo writes the document back with only the changed targets,
+// states and target language rewritten, everything else as it was read:
+//
+//	doc, err := xliff.Parse(in)
+//	if err != nil {
+//	  return err
+//	}
+//	if _, err := xliff.Translate(ctx, t, doc, "en", "fr"); err != nil {
+//	  return err
+//	}
+//	_, err = doc.WriteTo(out)
+package xliff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Unit is a translation unit: a trans-unit in XLIFF 1.2, a segment of a unit
+// in XLIFF 2.0. Source and Target hold XML: text and inline elements.
+type Unit struct {
+	ID             string // trans-unit or unit id
+	Segment        string // segment id, XLIFF 2.0 only
+	Source         string
+	Target         string
+	State          string // target state in XLIFF 1.2, segment state in XLIFF 2.0
+	StateQualifier string // target state-qualifier in XLIFF 1.2, segment subState in XLIFF 2.0
+	Translate      bool   // false when marked translate="no"
+
+	orig       [3]string // Target, State and StateQualifier as read
+	hasTarget  bool
+	stateTag   span   // start tag holding the state attributes, if any
+	targetTag  span   // start tag of the target
+	target     span   // the target element
+	inner      span   // the content of the target
+	insertAt   int    // where a missing target goes: after the source
+	indent     string // whitespace before the source, repeated before a new target
+	targetName string // name of a new target element, with the prefix of the source
+}
+
+// Document is a parsed XLIFF document.
+type Document struct {
+	Version    string // "1.2" or "2.0"
+	SourceLang string
+	TargetLang string
+	Units      []*Unit
+
+	data       []byte
+	origTarget string
+	langTags   []span // start tags holding the target language
+}
+
+// span is a range of bytes of the document.
+type span struct {
+	start, end int
+}
+
+// Parse reads an XLIFF 1.2 or 2.0 document.
+func Parse(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{data: data}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	type frame struct {
+		name      string
+		translate bool
+	}
+	stack := []frame{{translate: true}}
+	var unit *Unit
+	var unitID string
+	var innerStart int
+	parent := func() string {
+		if len(stack) < 2 {
+			return ""
+		}
+		return stack[len(stack)-2].name
+	}
+	for {
+		start := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xliff: %v", err)
+		}
+		end := int(dec.InputOffset())
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			translate := stack[len(stack)-1].translate
+			if v, ok := attr(t, "translate"); ok {
+				translate = v != "no"
+			}
+			stack = append(stack, frame{t.Name.Local, translate})
+			tag := span{start, end}
+
+			switch t.Name.Local {
+			case "xliff":
+				doc.Version, _ = attr(t, "version")
+				if !doc.v2() {
+					break
+				}
+				doc.SourceLang, _ = attr(t, "srcLang")
+				doc.TargetLang, _ = attr(t, "trgLang")
+				doc.langTags = append(doc.langTags, tag)
+			case "file":
+				if doc.v2() {
+					break
+				}
+				if doc.SourceLang == "" {
+					doc.SourceLang, _ = attr(t, "source-language")
+					doc.TargetLang, _ = attr(t, "target-language")
+				}
+				doc.langTags = append(doc.langTags, tag)
+			case "trans-unit":
+				if !doc.v2() {
+					unit = &Unit{Translate: translate}
+					unit.ID, _ = attr(t, "id")
+				}
+			case "unit":
+				unitID, _ = attr(t, "id")
+			case "segment":
+				if doc.v2() {
+					unit = &Unit{ID: unitID, Translate: translate, stateTag: tag}
+					unit.Segment, _ = attr(t, "id")
+					unit.State, _ = attr(t, "state")
+					unit.StateQualifier, _ = attr(t, "subState")
+				}
+			case "source", "seg-source":
+				if unit == nil || (parent() != "trans-unit" && parent() != "segment") {
+					break
+				}
+				innerStart = end
+				if t.Name.Local == "source" {
+					ws := data[:start]
+					ws = ws[len(bytes.TrimRight(ws, " \t\r\n")):]
+					unit.indent = string(ws)
+					name := strings.TrimPrefix(string(data[start:end]), "<")
+					name = name[:strings.IndexAny(name, " \t\r\n/>")]
+					unit.targetName = strings.TrimSuffix(name, "source") + "target"
+				}
+			case "target":
+				if unit == nil || (parent() != "trans-unit" && parent() != "segment") {
+					break
+				}
+				unit.hasTarget = true
+				unit.targetTag = tag
+				unit.target.start = start
+				if !doc.v2() {
+					unit.stateTag = tag
+					unit.State, _ = attr(t, "state")
+					unit.StateQualifier, _ = attr(t, "state-qualifier")
+				}
+			}
+
+		case xml.EndElement:
+			name := t.Name.Local
+			direct := unit != nil && (parent() == "trans-unit" || parent() == "segment")
+			stack = stack[:len(stack)-1]
+			switch {
+			case direct && name == "source":
+				unit.Source = string(data[innerStart:start])
+				unit.insertAt = end
+			case direct && name == "seg-source":
+				unit.insertAt = end
+			case direct && name == "target":
+				unit.inner = span{unit.targetTag.end, start}
+				unit.target.end = end
+				unit.Target = string(data[unit.inner.start:unit.inner.end])
+			case unit != nil && (name == "trans-unit" && !doc.v2() || name == "segment" && doc.v2()):
+				unit.orig = [3]string{unit.Target, unit.State, unit.StateQualifier}
+				doc.Units = append(doc.Units, unit)
+				unit = nil
+			}
+		}
+	}
+	if doc.Version == "" {
+		return nil, fmt.Errorf("xliff: not an XLIFF document")
+	}
+	doc.origTarget = doc.TargetLang
+	return doc, nil
+}
+
+// v2 reports whether the document is XLIFF 2.x.
+func (d *Document) v2() bool {
+	return strings.HasPrefix(d.Version, "2")
+}
+
+func attr(t xml.StartElement, name string) (string, bool) {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// edit replaces a span of the document.
+type edit struct {
+	span
+	text string
+}
+
+// WriteTo writes the document with its changes.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var edits []edit
+	if d.TargetLang != d.origTarget {
+		name := "target-language"
+		if d.v2() {
+			name = "trgLang"
+		}
+		for _, tag := range d.langTags {
+			edits = append(edits, edit{tag, setAttr(string(d.data[tag.start:tag.end]), name, d.TargetLang)})
+		}
+	}
+	for _, u := range d.Units {
+		edits = append(edits, d.unitEdits(u)...)
+	}
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var b bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		b.Write(d.data[last:e.start])
+		b.WriteString(e.text)
+		last = e.end
+	}
+	b.Write(d.data[last:])
+	return b.WriteTo(w)
+}
+
+// unitEdits returns the edits writing the changes of u.
+func (d *Document) unitEdits(u *Unit) []edit {
+	if [3]string{u.Target, u.State, u.StateQualifier} == u.orig {
+		return nil
+	}
+	stateName, qualifierName := "state", "state-qualifier"
+	if d.v2() {
+		qualifierName = "subState"
+	}
+	setState := func(tag string) string {
+		if u.State != "" {
+			tag = setAttr(tag, stateName, u.State)
+		}
+		if u.StateQualifier != "" {
+			tag = setAttr(tag, qualifierName, u.StateQualifier)
+		}
+		return tag
+	}
+
+	var edits []edit
+	if d.v2() && u.stateTag.end > 0 {
+		edits = append(edits, edit{u.stateTag, setState(string(d.data[u.stateTag.start:u.stateTag.end]))})
+	}
+	switch {
+	case !u.hasTarget:
+		tag := "<" + u.targetName + ">"
+		if !d.v2() {
+			tag = setState(tag)
+		}
+		edits = append(edits, edit{span{u.insertAt, u.insertAt}, u.indent + tag + u.Target + "</" + u.targetName + ">"})
+	default:
+		tag := string(d.data[u.targetTag.start:u.targetTag.end])
+		if !d.v2() {
+			tag = setState(tag)
+		}
+		if u.inner.start == u.inner.end && u.target.end == u.targetTag.end {
+			// A self-closing target is opened up.
+			name := strings.TrimPrefix(tag, "<")
+			name = name[:strings.IndexAny(name, " \t\r\n/>")]
+			tag = strings.TrimRight(strings.TrimSuffix(tag, ">"), " \t\r\n/") + ">"
+			edits = append(edits, edit{u.target, tag + u.Target + "</" + name + ">"})
+			break
+		}
+		edits = append(edits, edit{u.targetTag, tag}, edit{u.inner, u.Target})
+	}
+	return edits
+}
+
+// setAttr sets an attribute of a start tag, replacing its value or adding it
+// at the end.
+func setAttr(tag, name, value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	value = `"` + b.String() + `"`
+	re := regexp.MustCompile(`(\s` + regexp.QuoteMeta(name) + `\s*=\s*)("[^"]*"|'[^']*')`)
+	if loc := re.FindStringSubmatchIndex(tag); loc != nil {
+		return tag[:loc[4]] + value + tag[loc[5]:]
+	}
+	end := strings.TrimRight(strings.TrimSuffix(tag, ">"), " \t\r\n")
+	closing := tag[len(end):]
+	if strings.HasSuffix(end, "/") {
+		end, closing = strings.TrimRight(strings.TrimSuffix(end, "/"), " \t\r\n"), "/>"
+	}
+	return end + " " + name + "=" + value + closing
+}
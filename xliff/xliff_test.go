@@ -0,0 +1,127 @@
+package xliff
+
+import (
+	"strings"
+	"testing"
+)
+
+const xliff12 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file source-language="en" datatype="plaintext" original="app">
+    <body>
+      <trans-unit id="greeting">
+        <source>Hello <g id="1">world</g>!</source>
+      </trans-unit>
+      <trans-unit id="save">
+        <source>Save <x id="1"/> &amp; close</source>
+        <target state="translated">Enregistrer <x id="1"/> et fermer</target>
+        <alt-trans><source>Save</source><target>Sauver</target></alt-trans>
+      </trans-unit>
+      <trans-unit id="empty" translate="no">
+        <source>Brand</source>
+        <target/>
+      </trans-unit>
+    </body>
+  </file>
+</xliff>
+`
+
+const xliff20 = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:2.0" version="2.0" srcLang="en" trgLang="de">
+  <file id="f1">
+    <unit id="u1">
+      <segment id="s1" state="initial">
+        <source>Click <pc id="1">here</pc> <ph id="2"/></source>
+      </segment>
+      <ignorable><source> </source></ignorable>
+      <segment id="s2">
+        <source>Done</source>
+        <target>Fertig</target>
+      </segment>
+    </unit>
+  </file>
+</xliff>
+`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(strings.NewReader(xliff12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "1.2" || doc.SourceLang != "en" || doc.TargetLang != "" || len(doc.Units) != 3 {
+		t.Fatalf("got %+v", doc)
+	}
+	if u := doc.Units[0]; u.ID != "greeting" || u.Source != `Hello <g id="1">world</g>!` || u.hasTarget || !u.Translate {
+		t.Fatalf("got %+v", u)
+	}
+	if u := doc.Units[1]; u.Target != `Enregistrer <x id="1"/> et fermer` || u.State != "translated" {
+		t.Fatalf("got %+v", u)
+	}
+	if u := doc.Units[2]; u.Translate || !u.hasTarget || u.Target != "" {
+		t.Fatalf("got %+v", u)
+	}
+
+	doc, err = Parse(strings.NewReader(xliff20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "2.0" || doc.TargetLang != "de" || len(doc.Units) != 2 {
+		t.Fatalf("got %+v", doc)
+	}
+	if u := doc.Units[0]; u.ID != "u1" || u.Segment != "s1" || u.State != "initial" || u.Source != `Click <pc id="1">here</pc> <ph id="2"/>` {
+		t.Fatalf("got %+v", u)
+	}
+	if u := doc.Units[1]; u.Segment != "s2" || u.Target != "Fertig" {
+		t.Fatalf("got %+v", u)
+	}
+
+	if _, err := Parse(strings.NewReader("<html></html>")); err == nil {
+		t.Fatal("expected an error for a document that is not XLIFF")
+	}
+	if _, err := Parse(strings.NewReader(`<xliff version="1.2"><file>`)); err == nil {
+		t.Fatal("expected an error for a truncated document")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	doc, err := Parse(strings.NewReader(xliff12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	doc.WriteTo(&b)
+	if b.String() != xliff12 {
+		t.Fatalf("unchanged document rewritten:\n%s", b.String())
+	}
+
+	doc.TargetLang = "fr"
+	doc.Units[0].Target = "Bonjour"
+	doc.Units[1].State = "final"
+	doc.Units[2].Target = "Marque"
+	doc.Units[2].State = "new"
+	b.Reset()
+	doc.WriteTo(&b)
+	want := strings.NewReplacer(
+		`original="app">`, `original="app" target-language="fr">`,
+		`<g id="1">world</g>!</source>`, `<g id="1">world</g>!</source>`+"\n        <target>Bonjour</target>",
+		`<target state="translated">`, `<target state="final">`,
+		`<target/>`, `<target state="new">Marque</target>`,
+	).Replace(xliff12)
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestSetAttr(t *testing.T) {
+	tests := []struct{ tag, want string }{
+		{`<target>`, `<target state="new">`},
+		{`<target state='old' >`, `<target state="new" >`},
+		{`<target subState="x:y"/>`, `<target subState="x:y" state="new"/>`},
+		{`<target state-qualifier="a" state="b">`, `<target state-qualifier="a" state="new">`},
+	}
+	for _, tt := range tests {
+		if got := setAttr(tt.tag, "state", "new"); got != tt.want {
+			t.Errorf("setAttr(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}